@@ -4,8 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"log/slog"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"sync"
 
 	httplib "github.com/golibry/go-http/http"
@@ -16,19 +20,54 @@ type Cleanup func() error
 type ContextCleanup func(context.Context) error
 
 type App[C any] struct {
-	config   C
-	cleanup  []ContextCleanup
-	services map[reflect.Type]any
-	mu       sync.Mutex
-	closed   bool
+	*Lifecycle
+
+	config        C
+	cleanup       []cleanupHook
+	services      map[serviceKey]any
+	providers     map[serviceKey]serviceProvider[C]
+	registrations map[serviceKey]serviceRegistration
+	groups        map[reflect.Type][]groupService
+	mu            sync.Mutex
+	resolveMu     sync.Mutex
+	closed        bool
 }
 
 type ContainerOptions struct {
 	Log                  config.Log
 	Database             *config.Database
-	ErrorCategories      func() []*httplib.ErrorCategory
+	Databases            map[string]config.Database
 	DisableDefaultLogger bool
 	PingDBOnStartup      bool
+
+	// Problems configures the problem+json responses of the ResponseBuilder. Its
+	// categories also classify the errors of ResponseBuilder.NewErrorBuilder.
+	Problems ProblemOptions
+
+	// ErrorCategories overrides the categories of ResponseBuilder.NewErrorBuilder only.
+	//
+	// Deprecated: set Problems.Categories, which classifies the errors of both
+	// NewErrorBuilder and problem responses.
+	ErrorCategories func() []*httplib.ErrorCategory
+
+	// Encoders are registered with ResponseBuilder.RegisterEncoder for content
+	// negotiation, next to the DefaultEncoders. The TemplateEngine of Templates is
+	// registered as the HTML encoder.
+	Encoders []Encoder
+
+	// Templates configures the TemplateEngine, which is not created when nil.
+	Templates *TemplateOptions
+
+	// QueryObservers are notified of every database operation, e.g. metrics.
+	QueryObservers []QueryObserver
+
+	// QueryTracers are called before every database operation, e.g. to start tracing
+	// spans.
+	QueryTracers []QueryTracer
+
+	// PoolStatsObservers receive the pool statistics of every database sampled with a
+	// positive config.Database.StatsInterval, e.g. NewPoolStatsMetrics.
+	PoolStatsObservers []PoolStatsObserver
 }
 
 type Container[C any] struct {
@@ -36,6 +75,14 @@ type Container[C any] struct {
 	loggerService   *LoggerService
 	dbService       *SQLDBService
 	responseBuilder *ResponseBuilder
+	templates       *TemplateEngine
+
+	// parent is the container a scope was created from, nil for the root container.
+	parent *Container[C]
+
+	// resolving holds the chain of services being constructed by providers.
+	// It is only set on the container handed to a provider.
+	resolving []serviceKey
 }
 
 type StandardConfig interface {
@@ -43,30 +90,73 @@ type StandardConfig interface {
 	DatabaseConfig() *config.Database
 }
 
+// NamedDatabasesConfig is implemented by configs with additional named databases,
+// which NewContainerFromConfig registers next to the main database.
+type NamedDatabasesConfig interface {
+	DatabasesConfig() map[string]config.Database
+}
+
 func New[C any](config C) *App[C] {
 	return &App[C]{
-		config: config,
+		Lifecycle: &Lifecycle{},
+		config:    config,
 	}
 }
 
 func NewContainerFromConfig[C StandardConfig](cfg C) (*Container[C], error) {
-	return NewContainer(
-		cfg,
-		ContainerOptions{
-			Log:      cfg.LogConfig(),
-			Database: cfg.DatabaseConfig(),
-		},
-	)
+	options := ContainerOptions{
+		Log:      cfg.LogConfig(),
+		Database: cfg.DatabaseConfig(),
+	}
+	if provider, ok := any(cfg).(config.AppProvider); ok && provider.AppRef() != nil {
+		appConfig := provider.AppRef()
+		options.Problems.HideDetails = appConfig.AppEnv == "prod"
+		if options.Log.LogRedact == nil {
+			redact := appConfig.AppEnv == "prod" || appConfig.AppEnv == "stg"
+			options.Log.LogRedact = &redact
+		}
+
+		templatesDir := filepath.Join(appConfig.AppBaseDir, DefaultTemplatesDir)
+		if info, err := os.Stat(templatesDir); err == nil && info.IsDir() {
+			options.Templates = &TemplateOptions{
+				Dir:    templatesDir,
+				Reload: appConfig.AppEnv == "loc" || appConfig.AppEnv == "dev",
+			}
+		}
+	}
+	if named, ok := any(cfg).(NamedDatabasesConfig); ok {
+		options.Databases = named.DatabasesConfig()
+	}
+
+	return NewContainer(cfg, options)
 }
 
 func NewContainer[C any](cfg C, options ContainerOptions) (*Container[C], error) {
 	root := New(cfg)
 
+	var redaction *RedactionOptions
+	if options.Log.LogRedact != nil && *options.Log.LogRedact {
+		redaction = &RedactionOptions{
+			Keys: append(append([]string{}, DefaultRedactedKeys...), options.Log.LogRedactKeys...),
+		}
+	}
+
 	loggerService, err := NewLoggerService(
 		options.Log.LogPath,
 		options.Log.LogLevel,
 		LoggerOptions{
 			SetDefault: !options.DisableDefaultLogger,
+			Format:     options.Log.LogFormat,
+			Sinks:      options.Log.LogSinks,
+			Rotation: RotationOptions{
+				MaxSize:        int64(options.Log.LogMaxSize) * 1024 * 1024,
+				MaxAge:         options.Log.LogMaxAge,
+				MaxBackups:     options.Log.LogMaxBackups,
+				Compress:       options.Log.LogCompress,
+				ReopenOnSighup: options.Log.LogReopenOnSighup,
+			},
+			DebugSignalDuration: options.Log.LogDebugSignalDuration,
+			Redaction:           redaction,
 		},
 	)
 	if err != nil {
@@ -74,21 +164,40 @@ func NewContainer[C any](cfg C, options ContainerOptions) (*Container[C], error)
 	}
 	root.RegisterCleanup(loggerService.Close)
 
+	responseBuilder := NewResponseBuilderService(
+		loggerService.Logger(),
+		options.ErrorCategories,
+		ResponseBuilderOptions{Problems: options.Problems},
+	)
+	for _, encoder := range options.Encoders {
+		responseBuilder.RegisterEncoder(encoder)
+	}
 	container := &Container[C]{
 		App:             root,
 		loggerService:   loggerService,
-		responseBuilder: NewResponseBuilderService(loggerService.Logger(), options.ErrorCategories),
+		responseBuilder: responseBuilder,
 	}
 	RegisterService(container, loggerService)
 	RegisterService(container, loggerService.Logger())
 	RegisterService(container, container.responseBuilder)
 
+	if options.Templates != nil {
+		templates, err := NewTemplateEngine(*options.Templates)
+		if err != nil {
+			_ = container.Close()
+			return nil, fmt.Errorf("failed to create template engine: %w", err)
+		}
+		container.templates = templates
+		RegisterService(container, templates)
+		responseBuilder.RegisterEncoder(templates)
+	}
+
 	if options.Database != nil {
-		dbService, err := NewDBService(
+		dbService, err := newContainerDBService(
+			"",
 			*options.Database,
-			SQLDBOptions{
-				PingOnStartup: options.PingDBOnStartup,
-			},
+			options,
+			loggerService.Named("sql"),
 		)
 		if err != nil {
 			_ = container.Close()
@@ -98,11 +207,70 @@ func NewContainer[C any](cfg C, options ContainerOptions) (*Container[C], error)
 		root.RegisterCleanup(dbService.Close)
 		RegisterService(container, dbService)
 		RegisterService(container, dbService.DB())
+		RegisterGroupService(container, dbService)
+		RegisterGroupService[C, HealthContributor](container, dbService)
+	}
+
+	names := make([]string, 0, len(options.Databases))
+	for name := range options.Databases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		dbService, err := newContainerDBService(
+			name,
+			options.Databases[name],
+			options,
+			loggerService.Named("sql").With("database", name),
+		)
+		if err != nil {
+			_ = container.Close()
+			return nil, fmt.Errorf("failed to create %q database: %w", name, err)
+		}
+		root.RegisterCleanup(dbService.Close)
+		RegisterNamedService(container, name, dbService)
+		RegisterNamedService(container, name, dbService.DB())
+		RegisterGroupService(container, dbService)
+		RegisterGroupService[C, HealthContributor](container, dbService)
 	}
 
 	return container, nil
 }
 
+func newContainerDBService(
+	name string,
+	database config.Database,
+	options ContainerOptions,
+	sqlLogger *slog.Logger,
+) (*SQLDBService, error) {
+	observers := append([]QueryObserver{}, options.QueryObservers...)
+	if database.SlowQueryThreshold > 0 {
+		observers = append(observers, NewSlowQueryLogger(sqlLogger, database.SlowQueryThreshold))
+	}
+
+	statsObservers := append([]PoolStatsObserver{}, options.PoolStatsObservers...)
+	if database.PoolWaitWarnThreshold > 0 {
+		statsObservers = append(
+			statsObservers,
+			NewPoolWaitLogger(sqlLogger, database.PoolWaitWarnThreshold),
+		)
+	}
+
+	return NewDBService(
+		database,
+		SQLDBOptions{
+			PingOnStartup:  options.PingDBOnStartup,
+			ConnectRetry:   ConnectRetryPolicyFromConfig(database),
+			Logger:         sqlLogger,
+			QueryObservers: observers,
+			QueryTracers:   options.QueryTracers,
+			Name:           name,
+			StatsInterval:  database.StatsInterval,
+			StatsObservers: statsObservers,
+		},
+	)
+}
+
 func (a *App[C]) Config() C {
 	return a.config
 }
@@ -123,6 +291,16 @@ func (c *Container[C]) DBService() *SQLDBService {
 	return c.dbService
 }
 
+// NamedDBService returns a database registered by name from ContainerOptions.Databases.
+func (c *Container[C]) NamedDBService(name string) (*SQLDBService, bool) {
+	return NamedService[C, *SQLDBService](c, name)
+}
+
+// DBServices returns the main and the named databases registered by NewContainer.
+func (c *Container[C]) DBServices() []*SQLDBService {
+	return GroupServices[C, *SQLDBService](c)
+}
+
 func (c *Container[C]) DbService() *SQLDBService {
 	return c.DBService()
 }
@@ -143,7 +321,27 @@ func (c *Container[C]) ResponseBuilder() *ResponseBuilder {
 	return c.responseBuilder
 }
 
+// Templates returns the template engine, nil when ContainerOptions.Templates is not set.
+func (c *Container[C]) Templates() *TemplateEngine {
+	return c.templates
+}
+
 func RegisterService[C any, T any](container *Container[C], service T) {
+	registerService(container, serviceKey{typ: serviceType[T]()}, service, callerOrigin(2))
+}
+
+// RegisterNamedService registers a service under a name, next to the unnamed default
+// registered with RegisterService, e.g. a primary and an analytics *sql.DB.
+func RegisterNamedService[C any, T any](container *Container[C], name string, service T) {
+	registerService(
+		container,
+		serviceKey{typ: serviceType[T](), name: name},
+		service,
+		callerOrigin(2),
+	)
+}
+
+func registerService[C any](container *Container[C], key serviceKey, service any, origin string) {
 	if container == nil || container.App == nil {
 		return
 	}
@@ -152,31 +350,84 @@ func RegisterService[C any, T any](container *Container[C], service T) {
 	defer container.mu.Unlock()
 
 	if container.services == nil {
-		container.services = make(map[reflect.Type]any)
+		container.services = make(map[serviceKey]any)
 	}
-	container.services[serviceType[T]()] = service
+	container.services[key] = service
+	container.register(
+		key,
+		serviceRegistration{binding: bindingInstance, scope: container.scope(), origin: origin},
+	)
 }
 
+// Service returns the service registered for T, constructing it through its provider
+// on first use. Use ResolveService when the construction error is needed.
 func Service[C any, T any](container *Container[C]) (T, bool) {
-	var zero T
-	if container == nil || container.App == nil {
+	return NamedService[C, T](container, "")
+}
+
+// NamedService returns the service registered for T under name. The empty name
+// refers to the unnamed default.
+func NamedService[C any, T any](container *Container[C], name string) (T, bool) {
+	service, err := ResolveNamedService[C, T](container, name)
+	if err != nil {
+		var zero T
 		return zero, false
 	}
 
+	return service, true
+}
+
+// RegisterGroupService adds a service to the group bound to T. Groups hold several
+// implementations of one interface, e.g. multiple notifiers.
+func RegisterGroupService[C any, T any](container *Container[C], service T) {
+	if container == nil || container.App == nil {
+		return
+	}
+
 	container.mu.Lock()
 	defer container.mu.Unlock()
 
-	service, ok := container.services[serviceType[T]()]
-	if !ok {
-		return zero, false
+	if container.groups == nil {
+		container.groups = make(map[reflect.Type][]groupService)
 	}
+	typ := serviceType[T]()
+	container.groups[typ] = append(
+		container.groups[typ],
+		groupService{service: service, origin: callerOrigin(2)},
+	)
+}
 
-	typed, ok := service.(T)
-	if !ok {
-		return zero, false
+// GroupServices returns the services in the group bound to T in registration order.
+func GroupServices[C any, T any](container *Container[C]) []T {
+	if container == nil || container.App == nil {
+		return nil
+	}
+
+	container.mu.Lock()
+	defer container.mu.Unlock()
+
+	group := container.groups[serviceType[T]()]
+	services := make([]T, 0, len(group))
+	for _, member := range group {
+		if typed, ok := member.service.(T); ok {
+			services = append(services, typed)
+		}
+	}
+
+	return services
+}
+
+type serviceKey struct {
+	typ  reflect.Type
+	name string
+}
+
+func (k serviceKey) String() string {
+	if k.name == "" {
+		return k.typ.String()
 	}
 
-	return typed, true
+	return fmt.Sprintf("%s (%s)", k.typ, k.name)
 }
 
 func serviceType[T any]() reflect.Type {
@@ -188,13 +439,26 @@ func (a *App[C]) RegisterCleanup(cleanup Cleanup) {
 		return
 	}
 
-	a.RegisterContextCleanup(func(context.Context) error {
-		return cleanup()
-	})
+	a.registerCleanup(
+		cleanupHook{
+			run: func(context.Context) error {
+				return cleanup()
+			},
+			origin: callerOrigin(2),
+		},
+	)
 }
 
 func (a *App[C]) RegisterContextCleanup(cleanup ContextCleanup) {
-	if a == nil || cleanup == nil {
+	if cleanup == nil {
+		return
+	}
+
+	a.registerCleanup(cleanupHook{run: cleanup, origin: callerOrigin(2)})
+}
+
+func (a *App[C]) registerCleanup(hook cleanupHook) {
+	if a == nil {
 		return
 	}
 
@@ -204,7 +468,7 @@ func (a *App[C]) RegisterContextCleanup(cleanup ContextCleanup) {
 		return
 	}
 
-	a.cleanup = append(a.cleanup, cleanup)
+	a.cleanup = append(a.cleanup, hook)
 }
 
 func (a *App[C]) Close() error {
@@ -222,7 +486,7 @@ func (a *App[C]) CloseContext(ctx context.Context) error {
 		return nil
 	}
 	a.closed = true
-	cleanup := append([]ContextCleanup(nil), a.cleanup...)
+	cleanup := append([]cleanupHook(nil), a.cleanup...)
 	a.cleanup = nil
 	a.mu.Unlock()
 
@@ -232,7 +496,7 @@ func (a *App[C]) CloseContext(ctx context.Context) error {
 
 	errs := make([]error, 0)
 	for i := len(cleanup) - 1; i >= 0; i-- {
-		if err := cleanup[i](ctx); err != nil {
+		if err := cleanup[i].run(ctx); err != nil {
 			errs = append(errs, err)
 		}
 	}
@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"database/sql"
 	"log/slog"
 	"reflect"
 	"sync"
@@ -125,3 +126,85 @@ func TestNewContainerFromConfigUsesStandardConfig(t *testing.T) {
 		t.Fatal("Logger() = nil")
 	}
 }
+
+func TestContainerRegistersNamedDatabases(t *testing.T) {
+	container, err := NewContainer(
+		struct{}{},
+		ContainerOptions{
+			Log: config.Log{
+				LogLevel: slog.LevelInfo,
+				LogPath:  "stdout",
+			},
+			Databases: map[string]config.Database{
+				"audit": {Driver: "apptest", Dsn: "audit"},
+			},
+			DisableDefaultLogger: true,
+		},
+	)
+	if err != nil {
+		t.Fatalf("NewContainer() error = %v", err)
+	}
+	defer func() { _ = container.Close() }()
+
+	audit, ok := container.NamedDBService("audit")
+	if !ok || audit == nil {
+		t.Fatal("NamedDBService(audit) was not registered")
+	}
+	if db, _ := NamedService[struct{}, *sql.DB](container, "audit"); db != audit.DB() {
+		t.Fatal("named *sql.DB was not registered")
+	}
+	if container.DBService() != nil {
+		t.Fatal("named database was registered as the main database")
+	}
+}
+
+type testNotifier interface {
+	Notify() string
+}
+
+type testNamedNotifier string
+
+func (n testNamedNotifier) Notify() string {
+	return string(n)
+}
+
+func TestNamedServicesAreKeptNextToDefault(t *testing.T) {
+	container := &Container[struct{}]{App: New(struct{}{})}
+	primary := &testRepository{name: "primary"}
+	analytics := &testRepository{name: "analytics"}
+
+	RegisterService(container, primary)
+	RegisterNamedService(container, "analytics", analytics)
+
+	service, ok := Service[struct{}, *testRepository](container)
+	if !ok || service != primary {
+		t.Fatalf("Service() = %v, want primary", service)
+	}
+
+	named, ok := NamedService[struct{}, *testRepository](container, "analytics")
+	if !ok || named != analytics {
+		t.Fatalf("NamedService() = %v, want analytics", named)
+	}
+
+	if _, ok := NamedService[struct{}, *testRepository](container, "missing"); ok {
+		t.Fatal("NamedService() for missing name ok = true, want false")
+	}
+}
+
+func TestGroupServicesPreserveRegistrationOrder(t *testing.T) {
+	container := &Container[struct{}]{App: New(struct{}{})}
+
+	RegisterGroupService[struct{}, testNotifier](container, testNamedNotifier("email"))
+	RegisterGroupService[struct{}, testNotifier](container, testNamedNotifier("sms"))
+	RegisterGroupService[struct{}, testNotifier](container, testNamedNotifier("slack"))
+
+	names := make([]string, 0)
+	for _, notifier := range GroupServices[struct{}, testNotifier](container) {
+		names = append(names, notifier.Notify())
+	}
+
+	expected := []string{"email", "sms", "slack"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("GroupServices() = %v, want %v", names, expected)
+	}
+}
@@ -0,0 +1,460 @@
+package app
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/golibry/go-web-skeleton/framework/config"
+)
+
+const (
+	// DefaultMaxBodySize limits JSON and URL encoded form bodies.
+	DefaultMaxBodySize = 1 << 20
+
+	// DefaultMaxMultipartSize limits multipart form bodies, including files.
+	DefaultMaxMultipartSize = 32 << 20
+
+	// DefaultMaxMultipartMemory is the part of a multipart body kept in memory, the rest
+	// of the files is stored on disk.
+	DefaultMaxMultipartMemory = 8 << 20
+)
+
+// Bind sources, reported in BindError.Source.
+const (
+	BindSourcePath  = "path"
+	BindSourceQuery = "query"
+	BindSourceBody  = "body"
+)
+
+// BindError is a malformed request value, e.g. invalid JSON, an unknown field or a
+// query value of the wrong type. Problem responses report it as 400 Bad Request.
+type BindError struct {
+	Source string
+
+	// Field is the name of the invalid field, empty when the input as a whole is invalid.
+	Field string
+	Err   error
+}
+
+func (e *BindError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("invalid request %s: %v", e.Source, e.Err)
+	}
+
+	return fmt.Sprintf("invalid request %s field %q: %v", e.Source, e.Field, e.Err)
+}
+
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// BodyTooLargeError is a request body over the bind limit, reported as 413 Content Too Large.
+type BodyTooLargeError struct {
+	Limit int64
+}
+
+func (e *BodyTooLargeError) Error() string {
+	return fmt.Sprintf("request body exceeds %d bytes", e.Limit)
+}
+
+// UnsupportedMediaTypeError is a request body Bind cannot decode, reported as
+// 415 Unsupported Media Type.
+type UnsupportedMediaTypeError struct {
+	MediaType string
+}
+
+func (e *UnsupportedMediaTypeError) Error() string {
+	return fmt.Sprintf("unsupported request media type %q", e.MediaType)
+}
+
+// BindOptions limits the request bodies decoded by BindWith. Zero values use the defaults.
+type BindOptions struct {
+	MaxBodySize        int64
+	MaxMultipartSize   int64
+	MaxMultipartMemory int64
+}
+
+var (
+	errUnsupportedBindType = errors.New("unsupported bind field type")
+
+	fileHeaderType        = reflect.TypeFor[*multipart.FileHeader]()
+	fileHeadersType       = reflect.TypeFor[[]*multipart.FileHeader]()
+	textUnmarshalerType   = reflect.TypeFor[encoding.TextUnmarshaler]()
+	durationType          = reflect.TypeFor[time.Duration]()
+	sharedRequestValidate = sync.OnceValues(newRequestValidator)
+)
+
+// Validator returns the validator shared by Bind, with the custom validation functions
+// of config.NewValidator registered. Fields are named after their json, form, query or
+// path tag in validation errors. Register application validation functions on it once,
+// at startup.
+func Validator() (*validator.Validate, error) {
+	return sharedRequestValidate()
+}
+
+// Bind decodes the request into a T and validates it with Validator, see BindWith.
+func Bind[T any](r *http.Request) (T, error) {
+	return BindWith[T](r, BindOptions{})
+}
+
+// BindWith decodes the request into a T and validates it with Validator.
+//
+// Struct fields are read from the query by their "query" tag, from the body, which
+// overrides the query, and from the path values of the route by their "path" tag, which
+// are bound last so that the body cannot change the resource the route addresses. JSON
+// bodies are decoded with encoding/json, URL encoded and multipart forms by the "form"
+// tag of the fields, with *multipart.FileHeader and []*multipart.FileHeader fields
+// receiving the files. Unknown body fields and bodies over the limits are rejected.
+//
+// Malformed input is returned as *BindError, *BodyTooLargeError or
+// *UnsupportedMediaTypeError and failed validations as validator.ValidationErrors,
+// which the default problem and error categories map to their status codes.
+func BindWith[T any](r *http.Request, options BindOptions) (T, error) {
+	var target T
+	if err := bindRequest(r, &target, options.withDefaults()); err != nil {
+		return target, err
+	}
+
+	value := reflect.ValueOf(&target).Elem()
+	for value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() == reflect.Struct {
+		validate, err := Validator()
+		if err != nil {
+			return target, err
+		}
+		if err := validate.Struct(value.Interface()); err != nil {
+			return target, err
+		}
+	}
+
+	return target, nil
+}
+
+func (o BindOptions) withDefaults() BindOptions {
+	if o.MaxBodySize <= 0 {
+		o.MaxBodySize = DefaultMaxBodySize
+	}
+	if o.MaxMultipartSize <= 0 {
+		o.MaxMultipartSize = DefaultMaxMultipartSize
+	}
+	if o.MaxMultipartMemory <= 0 {
+		o.MaxMultipartMemory = DefaultMaxMultipartMemory
+	}
+
+	return o
+}
+
+func bindRequest(r *http.Request, target any, options BindOptions) error {
+	value := reflect.ValueOf(target).Elem()
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		value = value.Elem()
+	}
+
+	if value.Kind() == reflect.Struct {
+		query := r.URL.Query()
+		if err := bindFields(value, "query", BindSourceQuery, lookupValues(query), nil, nil); err != nil {
+			return err
+		}
+	}
+
+	if err := bindBody(r, target, value, options); err != nil {
+		return err
+	}
+
+	if value.Kind() == reflect.Struct {
+		path := func(name string) ([]string, bool) {
+			if v := r.PathValue(name); v != "" {
+				return []string{v}, true
+			}
+			return nil, false
+		}
+		if err := bindFields(value, "path", BindSourcePath, path, nil, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func bindBody(r *http.Request, target any, value reflect.Value, options BindOptions) error {
+	if r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil && r.Header.Get("Content-Type") != "" {
+		return &UnsupportedMediaTypeError{MediaType: r.Header.Get("Content-Type")}
+	}
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return bindJSON(r, target, options.MaxBodySize)
+	case mediaType == "application/x-www-form-urlencoded" && value.Kind() == reflect.Struct:
+		r.Body = http.MaxBytesReader(nil, r.Body, options.MaxBodySize)
+		if err := r.ParseForm(); err != nil {
+			return bodyError(err, options.MaxBodySize)
+		}
+		return bindForm(value, r.PostForm, nil)
+	case mediaType == "multipart/form-data" && value.Kind() == reflect.Struct:
+		r.Body = http.MaxBytesReader(nil, r.Body, options.MaxMultipartSize)
+		if err := r.ParseMultipartForm(options.MaxMultipartMemory); err != nil {
+			return bodyError(err, options.MaxMultipartSize)
+		}
+		return bindForm(value, r.MultipartForm.Value, r.MultipartForm.File)
+	default:
+		return &UnsupportedMediaTypeError{MediaType: mediaType}
+	}
+}
+
+func bindJSON(r *http.Request, target any, limit int64) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, limit))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(target); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return &BindError{
+				Source: BindSourceBody,
+				Field:  typeErr.Field,
+				Err:    fmt.Errorf("must be a valid %s", typeErr.Type),
+			}
+		}
+		if field, ok := unknownJSONField(err); ok {
+			return &BindError{Source: BindSourceBody, Field: field, Err: errors.New("unknown field")}
+		}
+
+		return bodyError(err, limit)
+	}
+
+	if decoder.More() {
+		return &BindError{Source: BindSourceBody, Err: errors.New("unexpected data after the JSON value")}
+	}
+
+	return nil
+}
+
+// unknownJSONField returns the field named by the error of a decoder with
+// DisallowUnknownFields. encoding/json reports it only as `json: unknown field "name"`,
+// without an error type, so this is the one place relying on the message.
+func unknownJSONField(err error) (string, bool) {
+	field, ok := strings.CutPrefix(err.Error(), "json: unknown field ")
+	if !ok {
+		return "", false
+	}
+	if unquoted, unquoteErr := strconv.Unquote(field); unquoteErr == nil {
+		field = unquoted
+	}
+
+	return field, true
+}
+
+func bindForm(
+	value reflect.Value,
+	values map[string][]string,
+	files map[string][]*multipart.FileHeader,
+) error {
+	known := make(map[string]bool)
+	if err := bindFields(value, "form", BindSourceBody, lookupValues(values), files, known); err != nil {
+		return err
+	}
+
+	for name := range values {
+		if !known[name] {
+			return &BindError{Source: BindSourceBody, Field: name, Err: errors.New("unknown field")}
+		}
+	}
+	for name := range files {
+		if !known[name] {
+			return &BindError{Source: BindSourceBody, Field: name, Err: errors.New("unknown field")}
+		}
+	}
+
+	return nil
+}
+
+// bodyError converts an error reading the body to a *BodyTooLargeError or a *BindError.
+func bodyError(err error, limit int64) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return &BodyTooLargeError{Limit: limit}
+	}
+
+	return &BindError{Source: BindSourceBody, Err: err}
+}
+
+func lookupValues(values map[string][]string) func(name string) ([]string, bool) {
+	return func(name string) ([]string, bool) {
+		v, ok := values[name]
+		return v, ok && len(v) > 0
+	}
+}
+
+// bindFields sets the fields of value tagged with tag from lookup and files, including
+// the fields of embedded structs. The names of the tagged fields are added to known.
+func bindFields(
+	value reflect.Value,
+	tag string,
+	source string,
+	lookup func(name string) ([]string, bool),
+	files map[string][]*multipart.FileHeader,
+	known map[string]bool,
+) error {
+	valueType := value.Type()
+	for i := range valueType.NumField() {
+		field := valueType.Field(i)
+		fieldValue := value.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if field.Anonymous && name == "" && fieldValue.Kind() == reflect.Struct {
+			if err := bindFields(fieldValue, tag, source, lookup, files, known); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		if known != nil {
+			known[name] = true
+		}
+
+		switch field.Type {
+		case fileHeaderType:
+			if headers := files[name]; len(headers) > 0 {
+				fieldValue.Set(reflect.ValueOf(headers[0]))
+			}
+			continue
+		case fileHeadersType:
+			if headers := files[name]; len(headers) > 0 {
+				fieldValue.Set(reflect.ValueOf(headers))
+			}
+			continue
+		}
+
+		values, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		if err := setField(fieldValue, values); err != nil {
+			if errors.Is(err, errUnsupportedBindType) {
+				return fmt.Errorf("failed to bind field %s: %w", field.Name, err)
+			}
+			return &BindError{Source: source, Field: name, Err: err}
+		}
+	}
+
+	return nil
+}
+
+func setField(value reflect.Value, values []string) error {
+	if value.Kind() == reflect.Slice && !value.Addr().Type().Implements(textUnmarshalerType) {
+		slice := reflect.MakeSlice(value.Type(), len(values), len(values))
+		for i, v := range values {
+			if err := setValue(slice.Index(i), v); err != nil {
+				return err
+			}
+		}
+		value.Set(slice)
+		return nil
+	}
+
+	return setValue(value, values[0])
+}
+
+func setValue(value reflect.Value, s string) error {
+	if value.Kind() == reflect.Pointer {
+		elem := reflect.New(value.Type().Elem())
+		if err := setValue(elem.Elem(), s); err != nil {
+			return err
+		}
+		value.Set(elem)
+		return nil
+	}
+
+	if unmarshaler, ok := value.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := unmarshaler.UnmarshalText([]byte(s)); err != nil {
+			return fmt.Errorf("must be a valid %s", value.Type())
+		}
+		return nil
+	}
+
+	invalid := fmt.Errorf("must be a valid %s", value.Type())
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(s)
+	case reflect.Bool:
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return invalid
+		}
+		value.SetBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value.Type() == durationType {
+			v, err := time.ParseDuration(s)
+			if err != nil {
+				return invalid
+			}
+			value.SetInt(int64(v))
+			return nil
+		}
+		v, err := strconv.ParseInt(s, 10, value.Type().Bits())
+		if err != nil {
+			return invalid
+		}
+		value.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(s, 10, value.Type().Bits())
+		if err != nil {
+			return invalid
+		}
+		value.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(s, value.Type().Bits())
+		if err != nil {
+			return invalid
+		}
+		value.SetFloat(v)
+	default:
+		return fmt.Errorf("%w %s", errUnsupportedBindType, value.Type())
+	}
+
+	return nil
+}
+
+func newRequestValidator() (*validator.Validate, error) {
+	validate, err := config.NewValidator()
+	if err != nil {
+		return nil, err
+	}
+
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form", "query", "path"} {
+			if name, _, _ := strings.Cut(field.Tag.Get(tag), ","); name != "" && name != "-" {
+				return name
+			}
+		}
+		return ""
+	})
+
+	return validate, nil
+}
@@ -0,0 +1,222 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type bindPagination struct {
+	Limit int `query:"limit" validate:"omitempty,lte=100"`
+}
+
+type bindOrder struct {
+	bindPagination
+
+	ID      int64                 `path:"id" json:"id"`
+	Tags    []string              `query:"tag"`
+	Timeout time.Duration         `query:"timeout"`
+	Name    string                `json:"name" form:"name" validate:"required"`
+	Notes   *string               `json:"notes" form:"notes"`
+	Avatar  *multipart.FileHeader `form:"avatar"`
+}
+
+func serveBind(t *testing.T, request *http.Request) (bindOrder, error) {
+	t.Helper()
+
+	var (
+		order bindOrder
+		err   error
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orders/{id}", func(_ http.ResponseWriter, r *http.Request) {
+		order, err = BindWith[bindOrder](r, BindOptions{MaxBodySize: 64})
+	})
+	mux.ServeHTTP(httptest.NewRecorder(), request)
+
+	return order, err
+}
+
+func TestBindDecodesPathQueryAndJSON(t *testing.T) {
+	request := httptest.NewRequest(
+		http.MethodPost,
+		"/orders/42?limit=10&tag=a&tag=b&timeout=2s",
+		strings.NewReader(`{"name":"first","notes":"gift"}`),
+	)
+	request.Header.Set("Content-Type", "application/json")
+
+	order, err := serveBind(t, request)
+	if err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+
+	if order.ID != 42 || order.Limit != 10 || order.Timeout != 2*time.Second ||
+		len(order.Tags) != 2 || order.Tags[1] != "b" {
+		t.Fatalf("order = %+v, want the path and query values", order)
+	}
+	if order.Name != "first" || order.Notes == nil || *order.Notes != "gift" {
+		t.Fatalf("order = %+v, want the JSON body", order)
+	}
+}
+
+func TestBindPathValuesOverrideTheBody(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPut, "/orders/5", strings.NewReader(`{"id":6,"name":"first"}`))
+	request.Header.Set("Content-Type", "application/json")
+
+	order, err := serveBind(t, request)
+	if err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	if order.ID != 5 {
+		t.Fatalf("order.ID = %d, want the path value 5", order.ID)
+	}
+}
+
+func TestUnknownJSONFieldMatchesEncodingJSON(t *testing.T) {
+	decoder := json.NewDecoder(strings.NewReader(`{"name":"first","price":1}`))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(&struct {
+		Name string `json:"name"`
+	}{})
+	if field, ok := unknownJSONField(err); !ok || field != "price" {
+		t.Fatalf("unknownJSONField(%v) = %q, %v, want price", err, field, ok)
+	}
+	if _, ok := unknownJSONField(errors.New("unexpected EOF")); ok {
+		t.Fatal("unknownJSONField() matched another error")
+	}
+}
+
+func TestBindDecodesForms(t *testing.T) {
+	form := url.Values{"name": {"first"}, "notes": {"gift"}}
+	request := httptest.NewRequest(http.MethodPost, "/orders/1", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	order, err := serveBind(t, request)
+	if err != nil || order.Name != "first" || *order.Notes != "gift" {
+		t.Fatalf("Bind() = %+v, %v, want the form values", order, err)
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	_ = writer.WriteField("name", "second")
+	part, _ := writer.CreateFormFile("avatar", "avatar.png")
+	_, _ = part.Write([]byte("png"))
+	_ = writer.Close()
+	request = httptest.NewRequest(http.MethodPost, "/orders/1", body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+
+	order, err = serveBind(t, request)
+	if err != nil || order.Name != "second" || order.Avatar == nil || order.Avatar.Filename != "avatar.png" {
+		t.Fatalf("Bind() = %+v, %v, want the multipart values and file", order, err)
+	}
+}
+
+func TestBindRejectsMalformedRequests(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        string
+		check       func(err error) bool
+	}{
+		{
+			name:   "invalid query value",
+			target: "/orders/1?limit=ten",
+			check: func(err error) bool {
+				var bindErr *BindError
+				return errors.As(err, &bindErr) && bindErr.Source == BindSourceQuery && bindErr.Field == "limit"
+			},
+		},
+		{
+			name:        "unknown JSON field",
+			target:      "/orders/1",
+			contentType: "application/json",
+			body:        `{"name":"first","price":1}`,
+			check: func(err error) bool {
+				var bindErr *BindError
+				return errors.As(err, &bindErr) && bindErr.Field == "price"
+			},
+		},
+		{
+			name:        "unknown form field",
+			target:      "/orders/1",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "name=first&price=1",
+			check: func(err error) bool {
+				var bindErr *BindError
+				return errors.As(err, &bindErr) && bindErr.Field == "price"
+			},
+		},
+		{
+			name:        "oversize body",
+			target:      "/orders/1",
+			contentType: "application/json",
+			body:        `{"name":"` + strings.Repeat("a", 100) + `"}`,
+			check: func(err error) bool {
+				var tooLarge *BodyTooLargeError
+				return errors.As(err, &tooLarge) && tooLarge.Limit == 64
+			},
+		},
+		{
+			name:        "unsupported media type",
+			target:      "/orders/1",
+			contentType: "text/csv",
+			body:        "name\nfirst",
+			check: func(err error) bool {
+				var unsupported *UnsupportedMediaTypeError
+				return errors.As(err, &unsupported) && unsupported.MediaType == "text/csv"
+			},
+		},
+		{
+			name:        "failed validation",
+			target:      "/orders/1?limit=500",
+			contentType: "application/json",
+			body:        `{"notes":"gift"}`,
+			check: func(err error) bool {
+				var validationErrs validator.ValidationErrors
+				return errors.As(err, &validationErrs) && len(validationErrs) == 2 &&
+					validationErrs[0].Field() == "limit" && validationErrs[1].Field() == "name"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				request.Header.Set("Content-Type", tt.contentType)
+			}
+
+			_, err := serveBind(t, request)
+			if err == nil || !tt.check(err) {
+				t.Fatalf("Bind() error = %v (%T)", err, err)
+			}
+		})
+	}
+}
+
+func TestBindErrorsMapToProblemStatuses(t *testing.T) {
+	builder := NewResponseBuilderService(nil, nil)
+	request := httptest.NewRequest(http.MethodPost, "/orders/1", nil)
+
+	problem := builder.NewProblem(request, &BindError{Source: BindSourceBody, Field: "price", Err: errors.New("unknown field")})
+	if problem.Status != http.StatusBadRequest || len(problem.Errors) != 1 || problem.Errors[0].Field != "price" {
+		t.Fatalf("problem = %+v, want a 400 with the price field", problem)
+	}
+	if problem = builder.NewProblem(request, &BodyTooLargeError{Limit: 64}); problem.Status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413", problem.Status)
+	}
+	if problem = builder.NewProblem(request, &UnsupportedMediaTypeError{}); problem.Status != http.StatusUnsupportedMediaType {
+		t.Fatalf("status = %d, want 415", problem.Status)
+	}
+}
@@ -0,0 +1,287 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	DebugFormatText = "text"
+	DebugFormatJSON = "json"
+)
+
+type DebugCommand[C any] struct {
+	Container *Container[C]
+	Format    string
+}
+
+func NewDebugCommand[C any](container *Container[C]) *DebugCommand[C] {
+	return &DebugCommand[C]{
+		Container: container,
+		Format:    DebugFormatText,
+	}
+}
+
+func (c *DebugCommand[C]) Id() string {
+	return "container:debug"
+}
+
+func (c *DebugCommand[C]) Description() string {
+	return "Debugs the services and cleanups registered in the container"
+}
+
+func (c *DebugCommand[C]) DefineFlags(flagSet *flag.FlagSet) {
+	flagSet.StringVar(&c.Format, "format", DebugFormatText, "Output format: text or json")
+}
+
+func (c *DebugCommand[C]) ValidateFlags() error {
+	if c.Format != DebugFormatText && c.Format != DebugFormatJSON {
+		return fmt.Errorf("unsupported container debug format %q", c.Format)
+	}
+
+	return nil
+}
+
+func (c *DebugCommand[C]) Exec(writer io.Writer) error {
+	description := c.Container.Describe()
+
+	if c.Format == DebugFormatJSON {
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(description)
+	}
+
+	return WriteContainerDescription(writer, description)
+}
+
+// WriteContainerDescription writes a container description as aligned plain text.
+func WriteContainerDescription(writer io.Writer, description ContainerDescription) error {
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(table, "Services:")
+	_, _ = fmt.Fprintln(table, "TYPE\tNAME\tSCOPE\tBINDING\tCONSTRUCTED\tORIGIN")
+	for _, service := range description.Services {
+		name := service.Name
+		if name == "" {
+			name = "-"
+		}
+		_, _ = fmt.Fprintf(
+			table,
+			"%s\t%s\t%s\t%s\t%t\t%s\n",
+			service.Type,
+			name,
+			service.Scope,
+			service.Binding,
+			service.Constructed,
+			service.Origin,
+		)
+	}
+
+	_, _ = fmt.Fprintln(table, "\nCleanups:")
+	_, _ = fmt.Fprintln(table, "ORDER\tSERVICE\tORIGIN")
+	for _, cleanup := range description.Cleanups {
+		service := cleanup.Service
+		if service == "" {
+			service = "-"
+		}
+		_, _ = fmt.Fprintf(table, "%d\t%s\t%s\n", cleanup.Order, service, cleanup.Origin)
+	}
+
+	return table.Flush()
+}
+
+// DBStatsCommand prints the connection pool statistics and the health of the databases.
+// With URL set, it reads them from the db stats admin endpoint of the running server.
+// Otherwise it reports the pools the command opens itself from the container, which
+// show whether the databases are reachable but not the load of the server: their open
+// and in-use counts only cover the health check pings.
+type DBStatsCommand[C any] struct {
+	Container *Container[C]
+	Format    string
+	Timeout   time.Duration
+
+	// URL is the db stats admin endpoint of the running server, e.g.
+	// "http://127.0.0.1:8080/admin/db-stats".
+	URL string
+
+	// Token is the bearer token of the admin endpoint.
+	Token string
+}
+
+// DBStatsReport is the output of DBStatsCommand.
+type DBStatsReport struct {
+	Pools  []PoolStats    `json:"pools"`
+	Health []HealthStatus `json:"health"`
+}
+
+// NewDBStatsReport checks the health of services and then samples their pool
+// statistics, so the pings are included in them.
+func NewDBStatsReport(ctx context.Context, services []*SQLDBService) DBStatsReport {
+	contributors := make([]HealthContributor, 0, len(services))
+	for _, service := range services {
+		contributors = append(contributors, service)
+	}
+
+	report := DBStatsReport{Pools: make([]PoolStats, 0), Health: CheckHealth(ctx, contributors)}
+	for _, service := range services {
+		report.Pools = append(report.Pools, service.Stats()...)
+	}
+
+	return report
+}
+
+func NewDBStatsCommand[C any](container *Container[C]) *DBStatsCommand[C] {
+	return &DBStatsCommand[C]{
+		Container: container,
+		Format:    DebugFormatText,
+		Timeout:   5 * time.Second,
+	}
+}
+
+func (c *DBStatsCommand[C]) Id() string {
+	return "db:stats"
+}
+
+func (c *DBStatsCommand[C]) Description() string {
+	return "Shows the connection pool statistics and the health of the databases"
+}
+
+func (c *DBStatsCommand[C]) DefineFlags(flagSet *flag.FlagSet) {
+	flagSet.StringVar(&c.Format, "format", DebugFormatText, "Output format: text or json")
+	flagSet.DurationVar(&c.Timeout, "timeout", 5*time.Second, "Timeout of the health checks")
+	flagSet.StringVar(
+		&c.URL,
+		"url",
+		c.URL,
+		"Db stats admin endpoint of the running server, the pools of this command when empty",
+	)
+	flagSet.StringVar(&c.Token, "token", c.Token, "Bearer token of the admin endpoint")
+}
+
+func (c *DBStatsCommand[C]) ValidateFlags() error {
+	if c.Format != DebugFormatText && c.Format != DebugFormatJSON {
+		return fmt.Errorf("unsupported db stats format %q", c.Format)
+	}
+
+	return nil
+}
+
+func (c *DBStatsCommand[C]) Exec(writer io.Writer) error {
+	ctx, cancel := context.WithTimeout(BaseContext(), c.Timeout)
+	defer cancel()
+
+	var report DBStatsReport
+	if c.URL != "" {
+		var err error
+		if report, err = c.fetchReport(ctx); err != nil {
+			return err
+		}
+	} else {
+		services := c.Container.DBServices()
+		if len(services) == 0 {
+			return fmt.Errorf("no databases are configured")
+		}
+		report = NewDBStatsReport(ctx, services)
+	}
+
+	if c.Format == DebugFormatJSON {
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		if c.URL == "" {
+			_, _ = fmt.Fprint(
+				writer,
+				"Pools opened by this command, use -url for the pools of the running server.\n\n",
+			)
+		}
+		if err := WriteDBStatsReport(writer, report); err != nil {
+			return err
+		}
+	}
+
+	for _, status := range report.Health {
+		if !status.Healthy {
+			return fmt.Errorf("%s is unhealthy: %s", status.Name, status.Error)
+		}
+	}
+
+	return nil
+}
+
+// fetchReport reads the report of the running server from its admin endpoint.
+func (c *DBStatsCommand[C]) fetchReport(ctx context.Context) (DBStatsReport, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL, nil)
+	if err != nil {
+		return DBStatsReport{}, fmt.Errorf("invalid db stats URL: %w", err)
+	}
+	if c.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return DBStatsReport{}, fmt.Errorf("failed to fetch db stats: %w", err)
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	if response.StatusCode != http.StatusOK {
+		return DBStatsReport{}, fmt.Errorf("failed to fetch db stats: %s", response.Status)
+	}
+
+	var report DBStatsReport
+	if err := json.NewDecoder(response.Body).Decode(&report); err != nil {
+		return DBStatsReport{}, fmt.Errorf("failed to decode db stats: %w", err)
+	}
+
+	return report, nil
+}
+
+// WriteDBStatsReport writes a db stats report as aligned plain text.
+func WriteDBStatsReport(writer io.Writer, report DBStatsReport) error {
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(table, "Pools:")
+	_, _ = fmt.Fprintln(table, "DATABASE\tPOOL\tOPEN\tIN_USE\tIDLE\tWAIT_COUNT\tWAIT_DURATION\tMAX_LIFETIME_CLOSED")
+	for _, stats := range report.Pools {
+		_, _ = fmt.Fprintf(
+			table,
+			"%s\t%s\t%d\t%d\t%d\t%d\t%s\t%d\n",
+			stats.Database,
+			stats.Pool,
+			stats.OpenConnections,
+			stats.InUse,
+			stats.Idle,
+			stats.WaitCount,
+			stats.WaitDuration,
+			stats.MaxLifetimeClosed,
+		)
+	}
+
+	_, _ = fmt.Fprintln(table, "\nHealth:")
+	_, _ = fmt.Fprintln(table, "NAME\tHEALTHY\tDURATION\tERROR")
+	for _, status := range report.Health {
+		errorMessage := status.Error
+		if errorMessage == "" {
+			errorMessage = "-"
+		}
+		_, _ = fmt.Fprintf(
+			table,
+			"%s\t%t\t%s\t%s\n",
+			status.Name,
+			status.Healthy,
+			status.Duration.Round(time.Microsecond),
+			errorMessage,
+		)
+	}
+
+	return table.Flush()
+}
@@ -0,0 +1,169 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golibry/go-cli-command/cli"
+	"github.com/golibry/go-web-skeleton/framework/config"
+)
+
+var _ cli.Command = (*DebugCommand[struct{}])(nil)
+var _ cli.Command = (*DBStatsCommand[struct{}])(nil)
+
+func TestDescribeListsServicesAndCleanups(t *testing.T) {
+	container := &Container[struct{}]{App: New(struct{}{})}
+	RegisterService(container, &testRepository{name: "instance"})
+	RegisterNamedProvider(container, "audit", func(*Container[struct{}]) (*testRepository, error) {
+		return &testRepository{name: "audit", closed: &[]string{}}, nil
+	})
+	container.RegisterCleanup(func() error { return nil })
+
+	if _, err := ResolveNamedService[struct{}, *testRepository](container, "audit"); err != nil {
+		t.Fatalf("ResolveNamedService() error = %v", err)
+	}
+
+	description := container.Describe()
+	if len(description.Services) != 2 {
+		t.Fatalf("len(Services) = %d, want 2", len(description.Services))
+	}
+
+	instance := description.Services[0]
+	if instance.Name != "" || instance.Binding != bindingInstance || !instance.Constructed {
+		t.Fatalf("Services[0] = %+v, want constructed unnamed instance", instance)
+	}
+	if !strings.HasPrefix(instance.Origin, "app/command_test.go:") {
+		t.Fatalf("Services[0].Origin = %q, want test file origin", instance.Origin)
+	}
+
+	audit := description.Services[1]
+	if audit.Name != "audit" || audit.Binding != bindingProvider || !audit.Constructed {
+		t.Fatalf("Services[1] = %+v, want constructed audit provider", audit)
+	}
+
+	if len(description.Cleanups) != 2 {
+		t.Fatalf("len(Cleanups) = %d, want 2", len(description.Cleanups))
+	}
+	if description.Cleanups[0].Service != "*app.testRepository (audit)" {
+		t.Fatalf("Cleanups[0].Service = %q, want audit repository", description.Cleanups[0].Service)
+	}
+	if description.Cleanups[1].Order != 2 || description.Cleanups[1].Service != "" {
+		t.Fatalf("Cleanups[1] = %+v, want plain cleanup second", description.Cleanups[1])
+	}
+}
+
+func TestDebugCommandWritesJSON(t *testing.T) {
+	container := &Container[struct{}]{App: New(struct{}{})}
+	RegisterService(container, &testRepository{name: "instance"})
+
+	command := NewDebugCommand(container)
+	flagSet := flag.NewFlagSet(command.Id(), flag.ContinueOnError)
+	command.DefineFlags(flagSet)
+	if err := flagSet.Parse([]string{"-format", "json"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if err := command.ValidateFlags(); err != nil {
+		t.Fatalf("ValidateFlags() error = %v", err)
+	}
+
+	output := &bytes.Buffer{}
+	if err := command.Exec(output); err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+
+	var description ContainerDescription
+	if err := json.Unmarshal(output.Bytes(), &description); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(description.Services) != 1 || description.Services[0].Type != "*app.testRepository" {
+		t.Fatalf("Services = %+v, want one testRepository", description.Services)
+	}
+}
+
+func TestDebugCommandRejectsUnknownFormat(t *testing.T) {
+	command := NewDebugCommand(&Container[struct{}]{App: New(struct{}{})})
+	command.Format = "yaml"
+
+	if err := command.ValidateFlags(); err == nil {
+		t.Fatal("ValidateFlags() error = nil, want unsupported format error")
+	}
+}
+
+func TestDBStatsCommandReportsPoolsAndHealth(t *testing.T) {
+	container, err := NewContainer(
+		struct{}{},
+		ContainerOptions{
+			Log:      config.Log{LogLevel: slog.LevelInfo, LogPath: "stdout"},
+			Database: &config.Database{Driver: "apptest", Dsn: "main"},
+			Databases: map[string]config.Database{
+				"audit": {Driver: "apptest", Dsn: "audit"},
+			},
+			DisableDefaultLogger: true,
+		},
+	)
+	if err != nil {
+		t.Fatalf("NewContainer() error = %v", err)
+	}
+	defer func() { _ = container.Close() }()
+
+	output := &bytes.Buffer{}
+	command := NewDBStatsCommand(container)
+	command.Format = DebugFormatJSON
+	if err := command.Exec(output); err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+
+	var report DBStatsReport
+	if err := json.Unmarshal(output.Bytes(), &report); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if len(report.Pools) != 2 || report.Pools[0].Database != "main" || report.Pools[1].Database != "audit" {
+		t.Fatalf("Pools = %+v, want main and audit primaries", report.Pools)
+	}
+	if len(report.Health) != 2 || !report.Health[0].Healthy || report.Health[1].Name != "db:audit" {
+		t.Fatalf("Health = %+v, want healthy main and audit databases", report.Health)
+	}
+
+	testSQLDriver.setDown("audit", true)
+	defer testSQLDriver.setDown("audit", false)
+	if err := command.Exec(&bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "db:audit") {
+		t.Fatalf("Exec() error = %v, want the unhealthy audit database", err)
+	}
+}
+
+func TestDBStatsCommandReadsTheRunningServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer admin-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(DBStatsReport{
+			Pools:  []PoolStats{{Database: "main", Pool: "primary", OpenConnections: 8, InUse: 5}},
+			Health: []HealthStatus{{Name: "db:main", Healthy: true}},
+		})
+	}))
+	defer server.Close()
+
+	command := NewDBStatsCommand[struct{}](nil)
+	command.URL = server.URL
+	command.Token = "admin-token"
+	output := &bytes.Buffer{}
+	if err := command.Exec(output); err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	if !strings.Contains(output.String(), "main      primary  8     5") ||
+		strings.Contains(output.String(), "opened by this command") {
+		t.Fatalf("output = %q, want the pools of the server", output.String())
+	}
+
+	command.Token = "wrong"
+	if err := command.Exec(&bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("Exec() error = %v, want the refused request", err)
+	}
+}
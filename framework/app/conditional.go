@@ -0,0 +1,227 @@
+package app
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// PreconditionFailedError is an If-Match or If-None-Match precondition that does not
+// hold, e.g. an update of a resource changed since the client read it. Problem
+// responses report it as 412 Precondition Failed.
+type PreconditionFailedError struct {
+	// ETag is the current entity tag of the resource, empty when it does not exist.
+	ETag string
+}
+
+func (e *PreconditionFailedError) Error() string {
+	if e.ETag == "" {
+		return "precondition failed: the resource does not exist"
+	}
+
+	return fmt.Sprintf("precondition failed: the current ETag of the resource is %s", e.ETag)
+}
+
+// CacheOptions configures the caching headers of the responses of ResponseBuilder.JSON
+// given WithCache.
+type CacheOptions struct {
+	// ETag is the version of the resource, e.g. an updated_at timestamp or a revision
+	// counter, quoted into an entity tag. The body hash is used when empty.
+	ETag string
+
+	// Weak marks a caller supplied ETag as weak, for representations that are
+	// semantically but not byte for byte equivalent.
+	Weak bool
+
+	// LastModified is sent as Last-Modified and checked against If-Modified-Since.
+	LastModified time.Time
+
+	// CacheControl directives, e.g. "private", "max-age=60".
+	CacheControl []string
+
+	// Vary lists the request headers the response depends on, e.g. "Accept-Language".
+	Vary []string
+}
+
+// NewETag returns the strong entity tag of version.
+func NewETag(version string) string {
+	return `"` + strings.ReplaceAll(version, `"`, "") + `"`
+}
+
+// NewWeakETag returns the weak entity tag of version.
+func NewWeakETag(version string) string {
+	return "W/" + NewETag(version)
+}
+
+// ETagFor returns the strong entity tag of body, a hash of its content.
+func ETagFor(body []byte) string {
+	sum := sha256.Sum256(body)
+	return NewETag(hex.EncodeToString(sum[:16]))
+}
+
+// SetCacheControl sets the Cache-Control header to directives.
+func SetCacheControl(w http.ResponseWriter, directives ...string) {
+	if len(directives) > 0 {
+		w.Header().Set("Cache-Control", strings.Join(directives, ", "))
+	}
+}
+
+// AddVary adds fields to the Vary header, skipping those already listed.
+func AddVary(w http.ResponseWriter, fields ...string) {
+	header := w.Header()
+	listed := make(map[string]bool)
+	for _, value := range header.Values("Vary") {
+		for _, field := range strings.Split(value, ",") {
+			listed[strings.ToLower(strings.TrimSpace(field))] = true
+		}
+	}
+
+	for _, field := range fields {
+		if key := strings.ToLower(field); !listed[key] {
+			listed[key] = true
+			header.Add("Vary", field)
+		}
+	}
+}
+
+// NotModified reports whether the cached representation of the client is current:
+// If-None-Match matches etag or, without If-None-Match, the resource was not modified
+// since If-Modified-Since. Only GET and HEAD requests are considered.
+func NotModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etag != "" && etagListMatches(ifNoneMatch, etag, false)
+	}
+
+	if lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// CheckPreconditions checks the If-Match header of an update against the current
+// entity tag of the resource, empty when it does not exist, using the strong
+// comparison, then the If-None-Match header of methods other than GET and HEAD, e.g.
+// "If-None-Match: *" to create a resource only once. It returns a
+// *PreconditionFailedError when a precondition fails and nil without them.
+func CheckPreconditions(r *http.Request, currentETag string) error {
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		if currentETag == "" || !etagListMatches(ifMatch, currentETag, true) {
+			return &PreconditionFailedError{ETag: currentETag}
+		}
+	}
+
+	// GET and HEAD requests whose If-None-Match matches are answered with 304 Not
+	// Modified by JSON instead, see WithCache
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return nil
+	}
+	ifNoneMatch := r.Header.Get("If-None-Match")
+	if ifNoneMatch != "" && currentETag != "" && etagListMatches(ifNoneMatch, currentETag, false) {
+		return &PreconditionFailedError{ETag: currentETag}
+	}
+
+	return nil
+}
+
+// ResponseOption configures a response of ResponseBuilder.JSON.
+type ResponseOption func(options *responseOptions)
+
+type responseOptions struct {
+	request *http.Request
+	cache   *CacheOptions
+}
+
+// WithCache makes JSON send an ETag and the caching headers of options, answering the
+// conditional headers of r, which must not be nil: GET and HEAD requests whose cache is
+// current get a 304 Not Modified. The preconditions of other methods are checked with
+// CheckPreconditions before changing the resource, so their response only carries the
+// new ETag. The body is buffered to be hashed, and HEAD requests get the headers only.
+func WithCache(r *http.Request, options CacheOptions) ResponseOption {
+	return func(responseOptions *responseOptions) {
+		responseOptions.request = r
+		responseOptions.cache = &options
+	}
+}
+
+// cachedJSON writes v as JSON with the caching headers of options, see WithCache.
+func (rbs *ResponseBuilder) cachedJSON(
+	w http.ResponseWriter,
+	r *http.Request,
+	status int,
+	v any,
+	options CacheOptions,
+) error {
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return fmt.Errorf("failed to encode JSON response: %w", err)
+	}
+
+	etag := ETagFor(buf.Bytes())
+	if options.ETag != "" {
+		etag = NewETag(options.ETag)
+		if options.Weak {
+			etag = NewWeakETag(options.ETag)
+		}
+	}
+
+	header := w.Header()
+	header.Set("ETag", etag)
+	if !options.LastModified.IsZero() {
+		header.Set("Last-Modified", options.LastModified.UTC().Format(http.TimeFormat))
+	}
+	SetCacheControl(w, options.CacheControl...)
+	AddVary(w, options.Vary...)
+
+	if status >= 200 && status < 300 && NotModified(r, etag, options.LastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	header.Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return nil
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// etagListMatches reports whether the If-Match or If-None-Match list matches etag,
+// "*" matching any. The weak comparison ignores the W/ prefix.
+func etagListMatches(list string, etag string, strong bool) bool {
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+	if strong && strings.HasPrefix(etag, "W/") {
+		return false
+	}
+
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strong {
+			if candidate == etag {
+				return true
+			}
+			continue
+		}
+		if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,162 @@
+package app
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestJSONWithCacheAnswersNotModified(t *testing.T) {
+	builder := NewResponseBuilderService(slog.New(slog.DiscardHandler), nil)
+	body := map[string]int{"total": 3}
+	options := CacheOptions{CacheControl: []string{"private", "max-age=60"}, Vary: []string{"Accept"}}
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	if err := builder.JSON(recorder, http.StatusOK, body, WithCache(request, options)); err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+
+	etag := recorder.Header().Get("ETag")
+	if recorder.Code != http.StatusOK || etag == "" || recorder.Body.String() != "{\"total\":3}\n" {
+		t.Fatalf("response = %d %q %q, want the body with an ETag", recorder.Code, etag, recorder.Body.String())
+	}
+	if recorder.Header().Get("Cache-Control") != "private, max-age=60" || recorder.Header().Get("Vary") != "Accept" {
+		t.Fatalf("headers = %v, want the caching headers", recorder.Header())
+	}
+
+	recorder = httptest.NewRecorder()
+	request.Header.Set("If-None-Match", `"other", W/`+etag)
+	if err := builder.JSON(recorder, http.StatusOK, body, WithCache(request, options)); err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+	if recorder.Code != http.StatusNotModified || recorder.Body.Len() != 0 || recorder.Header().Get("ETag") != etag {
+		t.Fatalf("response = %d %q, want 304 with the ETag", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	changed := map[string]int{"total": 4}
+	if err := builder.JSON(recorder, http.StatusOK, changed, WithCache(request, options)); err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 for a changed body", recorder.Code)
+	}
+}
+
+func TestJSONWithCacheUsesVersionsAndModificationTimes(t *testing.T) {
+	builder := NewResponseBuilderService(slog.New(slog.DiscardHandler), nil)
+	modified := time.Date(2026, 5, 1, 10, 0, 0, 500, time.UTC)
+	options := CacheOptions{ETag: "rev-7", Weak: true, LastModified: modified}
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/orders/1", nil)
+	request.Header.Set("If-Modified-Since", modified.Format(http.TimeFormat))
+	if err := builder.JSON(recorder, http.StatusOK, "order", WithCache(request, options)); err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+
+	if recorder.Code != http.StatusNotModified || recorder.Header().Get("ETag") != `W/"rev-7"` {
+		t.Fatalf("response = %d %v, want 304 with the weak version ETag", recorder.Code, recorder.Header())
+	}
+
+	// If-None-Match takes precedence over If-Modified-Since.
+	recorder = httptest.NewRecorder()
+	request.Header.Set("If-None-Match", `"rev-6"`)
+	if err := builder.JSON(recorder, http.StatusOK, "order", WithCache(request, options)); err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 for another version", recorder.Code)
+	}
+}
+
+func TestJSONWithCacheAnswersUnsafeRequestsWithTheNewETag(t *testing.T) {
+	builder := NewResponseBuilderService(slog.New(slog.DiscardHandler), nil)
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPut, "/orders/1", nil)
+	request.Header.Set("If-None-Match", "*")
+
+	// The resource did not exist, so the precondition held and the handler created it
+	if err := CheckPreconditions(request, ""); err != nil {
+		t.Fatalf("CheckPreconditions() error = %v", err)
+	}
+	options := WithCache(request, CacheOptions{ETag: "rev-1"})
+	if err := builder.JSON(recorder, http.StatusCreated, "order", options); err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+
+	if recorder.Code != http.StatusCreated || recorder.Header().Get("ETag") != `"rev-1"` {
+		t.Fatalf("response = %d %v, want 201 with the new ETag", recorder.Code, recorder.Header())
+	}
+
+	err := builder.JSON(httptest.NewRecorder(), http.StatusOK, "order", WithCache(nil, CacheOptions{}))
+	if err == nil {
+		t.Fatal("JSON() error = nil, want an error for WithCache without a request")
+	}
+}
+
+func TestCheckPreconditions(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		ifMatch     string
+		ifNoneMatch string
+		current     string
+		wantErr     bool
+	}{
+		{name: "no precondition", current: `"v1"`},
+		{name: "matching", ifMatch: `"v0", "v1"`, current: `"v1"`},
+		{name: "any existing", ifMatch: "*", current: `"v1"`},
+		{name: "changed", ifMatch: `"v0"`, current: `"v1"`, wantErr: true},
+		{name: "weak", ifMatch: `W/"v1"`, current: `W/"v1"`, wantErr: true},
+		{name: "missing", ifMatch: "*", wantErr: true},
+		{name: "create once", ifNoneMatch: "*"},
+		{name: "already created", ifNoneMatch: "*", current: `"v1"`, wantErr: true},
+		{name: "none matching", ifNoneMatch: `W/"v1"`, current: `"v1"`, wantErr: true},
+		{name: "none matching other", ifNoneMatch: `"v0"`, current: `"v1"`},
+		{name: "none matching on GET", method: http.MethodGet, ifNoneMatch: "*", current: `"v1"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodPut
+			}
+			request := httptest.NewRequest(method, "/orders/1", nil)
+			if tt.ifMatch != "" {
+				request.Header.Set("If-Match", tt.ifMatch)
+			}
+			if tt.ifNoneMatch != "" {
+				request.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+
+			err := CheckPreconditions(request, tt.current)
+			var preconditionErr *PreconditionFailedError
+			if tt.wantErr != errors.As(err, &preconditionErr) {
+				t.Fatalf("CheckPreconditions() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	builder := NewResponseBuilderService(slog.New(slog.DiscardHandler), nil)
+	problem := builder.NewProblem(httptest.NewRequest(http.MethodPut, "/orders/1", nil), &PreconditionFailedError{ETag: `"v1"`})
+	if problem.Status != http.StatusPreconditionFailed {
+		t.Fatalf("status = %d, want 412", problem.Status)
+	}
+}
+
+func TestAddVarySkipsListedFields(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set("Vary", "Accept, Origin")
+
+	AddVary(recorder, "accept", "Accept-Language")
+
+	if values := recorder.Header().Values("Vary"); len(values) != 2 || values[1] != "Accept-Language" {
+		t.Fatalf("Vary = %v, want Accept-Language added once", values)
+	}
+}
@@ -0,0 +1,147 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+)
+
+const (
+	ScopeSingleton = "singleton"
+	ScopeScoped    = "scoped"
+
+	bindingInstance = "instance"
+	bindingProvider = "provider"
+	bindingGroup    = "group"
+)
+
+// ContainerDescription is a snapshot of what a container has registered.
+type ContainerDescription struct {
+	Services []ServiceDescription `json:"services"`
+	Cleanups []CleanupDescription `json:"cleanups"`
+}
+
+// ServiceDescription describes one service registration.
+type ServiceDescription struct {
+	Type        string `json:"type"`
+	Name        string `json:"name,omitempty"`
+	Scope       string `json:"scope"`
+	Binding     string `json:"binding"`
+	Constructed bool   `json:"constructed"`
+	Origin      string `json:"origin"`
+}
+
+// CleanupDescription describes one cleanup hook. Cleanups are listed in the order
+// CloseContext runs them.
+type CleanupDescription struct {
+	Order   int    `json:"order"`
+	Service string `json:"service,omitempty"`
+	Origin  string `json:"origin"`
+}
+
+type serviceRegistration struct {
+	binding string
+	scope   string
+	origin  string
+}
+
+type groupService struct {
+	service any
+	origin  string
+}
+
+type cleanupHook struct {
+	run     ContextCleanup
+	service string
+	origin  string
+}
+
+// Describe returns the registered services, sorted by type and name, and the cleanup
+// hooks in the order CloseContext will run them.
+func (c *Container[C]) Describe() ContainerDescription {
+	description := ContainerDescription{
+		Services: make([]ServiceDescription, 0),
+		Cleanups: make([]CleanupDescription, 0),
+	}
+	if c == nil || c.App == nil {
+		return description
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, registration := range c.registrations {
+		_, constructed := c.services[key]
+		description.Services = append(
+			description.Services,
+			ServiceDescription{
+				Type:        key.typ.String(),
+				Name:        key.name,
+				Scope:       registration.scope,
+				Binding:     registration.binding,
+				Constructed: constructed,
+				Origin:      registration.origin,
+			},
+		)
+	}
+	for typ, group := range c.groups {
+		for _, member := range group {
+			description.Services = append(
+				description.Services,
+				ServiceDescription{
+					Type:        typ.String(),
+					Scope:       ScopeSingleton,
+					Binding:     bindingGroup,
+					Constructed: true,
+					Origin:      member.origin,
+				},
+			)
+		}
+	}
+	sort.SliceStable(description.Services, func(i, j int) bool {
+		left, right := description.Services[i], description.Services[j]
+		if left.Type != right.Type {
+			return left.Type < right.Type
+		}
+		if left.Name != right.Name {
+			return left.Name < right.Name
+		}
+		return left.Binding < right.Binding
+	})
+
+	for i := len(c.cleanup) - 1; i >= 0; i-- {
+		description.Cleanups = append(
+			description.Cleanups,
+			CleanupDescription{
+				Order:   len(description.Cleanups) + 1,
+				Service: c.cleanup[i].service,
+				Origin:  c.cleanup[i].origin,
+			},
+		)
+	}
+
+	return description
+}
+
+// register records registration metadata. The caller must hold the lock.
+func (a *App[C]) register(key serviceKey, registration serviceRegistration) {
+	if a.registrations == nil {
+		a.registrations = make(map[serviceKey]serviceRegistration)
+	}
+	a.registrations[key] = registration
+}
+
+// callerOrigin returns the "dir/file.go:line" location of the caller skip frames up.
+func callerOrigin(skip int) string {
+	_, file, line, ok := runtime.Caller(skip)
+	if !ok {
+		return "unknown"
+	}
+
+	return fmt.Sprintf(
+		"%s:%d",
+		filepath.Join(filepath.Base(filepath.Dir(file)), filepath.Base(file)),
+		line,
+	)
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// HealthContributor is a component reporting its health, e.g. a database pinging its
+// server. Contributors are registered with RegisterGroupService[C, HealthContributor]
+// and checked together with CheckHealth.
+type HealthContributor interface {
+	HealthName() string
+	CheckHealth(ctx context.Context) error
+}
+
+// HealthStatus is the result of one HealthContributor check.
+type HealthStatus struct {
+	Name     string        `json:"name"`
+	Healthy  bool          `json:"healthy"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// CheckHealth runs the checks of the contributors concurrently and returns their
+// statuses in the contributors order.
+func CheckHealth(ctx context.Context, contributors []HealthContributor) []HealthStatus {
+	statuses := make([]HealthStatus, len(contributors))
+
+	var wg sync.WaitGroup
+	for i, contributor := range contributors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			start := time.Now()
+			err := contributor.CheckHealth(ctx)
+			statuses[i] = HealthStatus{
+				Name:     contributor.HealthName(),
+				Healthy:  err == nil,
+				Duration: time.Since(start),
+			}
+			if err != nil {
+				statuses[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return statuses
+}
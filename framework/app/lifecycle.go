@@ -0,0 +1,258 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const DefaultStopTimeout = 15 * time.Second
+
+var (
+	ErrLifecycleStarted = errors.New("lifecycle already started")
+	ErrShutdownSignal   = errors.New("shutdown signal received")
+)
+
+// Hook is a background component started and stopped by Lifecycle.Run.
+type Hook struct {
+	// Name identifies the hook in errors. It defaults to the registration origin.
+	Name string
+
+	OnStart func(context.Context) error
+	OnStop  func(context.Context) error
+
+	// StartTimeout bounds OnStart. Zero means no timeout.
+	StartTimeout time.Duration
+
+	// StopTimeout bounds OnStop. Zero means DefaultStopTimeout.
+	StopTimeout time.Duration
+}
+
+// Lifecycle starts hooks in registration order and stops them in reverse order.
+// The zero value is ready to use.
+type Lifecycle struct {
+	hooks        []Hook
+	mu           sync.Mutex
+	running      bool
+	ready        bool
+	start        *lifecycleStart
+	shutdownChan chan struct{}
+	shutdownErr  error
+	shuttingDown bool
+}
+
+// lifecycleStart is the outcome of starting the hooks, err is set before done is closed.
+type lifecycleStart struct {
+	done chan struct{}
+	err  error
+}
+
+// Append registers a hook. It panics once Run has been called, the hook would never run.
+func (l *Lifecycle) Append(hook Hook) {
+	if hook.Name == "" {
+		hook.Name = callerOrigin(2)
+	}
+	l.append(hook)
+}
+
+func (l *Lifecycle) OnStart(start func(context.Context) error) {
+	if start == nil {
+		return
+	}
+	l.append(Hook{Name: callerOrigin(2), OnStart: start})
+}
+
+func (l *Lifecycle) OnStop(stop func(context.Context) error) {
+	if stop == nil {
+		return
+	}
+	l.append(Hook{Name: callerOrigin(2), OnStop: stop})
+}
+
+func (l *Lifecycle) append(hook Hook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.running {
+		panic(fmt.Errorf("%w: cannot add hook %s", ErrLifecycleStarted, hook.Name))
+	}
+
+	l.hooks = append(l.hooks, hook)
+}
+
+// Run starts every hook in registration order, marks the lifecycle ready and blocks
+// until ctx is done or Shutdown is called, then stops the hooks in reverse order.
+//
+// When a hook fails to start, the hooks already started are stopped and the start
+// error is returned, also to WaitReady. Run can then be called again. Otherwise a
+// lifecycle runs once: once it stopped, Run returns ErrLifecycleStarted.
+func (l *Lifecycle) Run(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	l.mu.Lock()
+	if l.running {
+		l.mu.Unlock()
+		return ErrLifecycleStarted
+	}
+	l.running = true
+	if l.start != nil && l.start.err != nil {
+		l.start = nil
+	}
+	l.init()
+	start := l.start
+	hooks := append([]Hook(nil), l.hooks...)
+	l.mu.Unlock()
+
+	started, err := startHooks(ctx, hooks)
+	if err != nil {
+		err = errors.Join(err, stopHooks(ctx, hooks[:started]))
+
+		l.mu.Lock()
+		l.running = false
+		start.err = err
+		close(start.done)
+		l.mu.Unlock()
+
+		return err
+	}
+
+	l.mu.Lock()
+	l.ready = true
+	close(start.done)
+	l.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+	case <-l.shutdownChan:
+	}
+
+	l.mu.Lock()
+	l.ready = false
+	shutdownErr := l.shutdownErr
+	l.mu.Unlock()
+
+	return errors.Join(shutdownErr, stopHooks(ctx, hooks))
+}
+
+// Shutdown makes Run stop the hooks and return. A non-nil err is returned by Run,
+// which lets a component report a failure after it started.
+func (l *Lifecycle) Shutdown(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.shuttingDown {
+		return
+	}
+
+	l.init()
+	l.shuttingDown = true
+	l.shutdownErr = err
+	close(l.shutdownChan)
+}
+
+// Ready reports whether every hook has started and the lifecycle is not stopping.
+func (l *Lifecycle) Ready() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.ready
+}
+
+// WaitReady blocks until every hook has started or ctx is done. It returns the start
+// error when a hook failed to start.
+func (l *Lifecycle) WaitReady(ctx context.Context) error {
+	l.mu.Lock()
+	l.init()
+	start := l.start
+	l.mu.Unlock()
+
+	select {
+	case <-start.done:
+		return start.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// init creates the lifecycle channels. The caller must hold the lock.
+func (l *Lifecycle) init() {
+	if l.start == nil {
+		l.start = &lifecycleStart{done: make(chan struct{})}
+	}
+	if l.shutdownChan == nil {
+		l.shutdownChan = make(chan struct{})
+	}
+}
+
+func startHooks(ctx context.Context, hooks []Hook) (int, error) {
+	for i, hook := range hooks {
+		if hook.OnStart == nil {
+			continue
+		}
+
+		hookCtx, cancel := ctx, context.CancelFunc(func() {})
+		if hook.StartTimeout > 0 {
+			hookCtx, cancel = context.WithTimeout(ctx, hook.StartTimeout)
+		}
+		err := hook.OnStart(hookCtx)
+		cancel()
+		if err != nil {
+			return i, fmt.Errorf("failed to start %s: %w", hook.Name, err)
+		}
+	}
+
+	return len(hooks), nil
+}
+
+func stopHooks(ctx context.Context, hooks []Hook) error {
+	// Stopping must not be cut short by the cancellation that triggered it.
+	ctx = context.WithoutCancel(ctx)
+
+	errs := make([]error, 0)
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+		if hook.OnStop == nil {
+			continue
+		}
+
+		timeout := hook.StopTimeout
+		if timeout <= 0 {
+			timeout = DefaultStopTimeout
+		}
+		hookCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := hook.OnStop(hookCtx)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", hook.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// SignalContext returns a context canceled when the process receives SIGINT, SIGTERM
+// or SIGQUIT. The context cause wraps ErrShutdownSignal and names the signal.
+func SignalContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+
+	go func() {
+		defer signal.Stop(sigChan)
+
+		select {
+		case sig := <-sigChan:
+			cancel(fmt.Errorf("%w: %s", ErrShutdownSignal, sig))
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		cancel(context.Canceled)
+	}
+}
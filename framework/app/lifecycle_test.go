@@ -0,0 +1,197 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLifecycleStartsInOrderAndStopsInReverse(t *testing.T) {
+	app := New(struct{}{})
+	order := make([]string, 0)
+	for _, name := range []string{"worker", "consumer"} {
+		app.Append(Hook{
+			Name: name,
+			OnStart: func(context.Context) error {
+				order = append(order, "start "+name)
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				order = append(order, "stop "+name)
+				return nil
+			},
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- app.Run(ctx)
+	}()
+
+	if err := app.WaitReady(context.Background()); err != nil {
+		t.Fatalf("WaitReady() error = %v", err)
+	}
+	if !app.Ready() {
+		t.Fatal("Ready() = false, want true")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if app.Ready() {
+		t.Fatal("Ready() after stop = true, want false")
+	}
+
+	expected := []string{"start worker", "start consumer", "stop consumer", "stop worker"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("order = %v, want %v", order, expected)
+	}
+}
+
+func TestLifecycleRollsBackStartedHooksOnFailure(t *testing.T) {
+	lifecycle := &Lifecycle{}
+	order := make([]string, 0)
+	startErr := errors.New("cannot connect")
+
+	lifecycle.Append(Hook{
+		Name:    "cache",
+		OnStart: func(context.Context) error { order = append(order, "start cache"); return nil },
+		OnStop:  func(context.Context) error { order = append(order, "stop cache"); return nil },
+	})
+	lifecycle.Append(Hook{
+		Name:    "consumer",
+		OnStart: func(context.Context) error { return startErr },
+		OnStop:  func(context.Context) error { order = append(order, "stop consumer"); return nil },
+	})
+
+	err := lifecycle.Run(context.Background())
+	if !errors.Is(err, startErr) {
+		t.Fatalf("Run() error = %v, want %v", err, startErr)
+	}
+	if !strings.Contains(err.Error(), "failed to start consumer") {
+		t.Fatalf("Run() error = %q, want hook name", err.Error())
+	}
+
+	expected := []string{"start cache", "stop cache"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("order = %v, want %v", order, expected)
+	}
+}
+
+func TestLifecycleReportsStartFailureToWaitersAndCanBeRetried(t *testing.T) {
+	lifecycle := &Lifecycle{}
+	startErr := errors.New("cannot connect")
+	attempts := 0
+	retried := make(chan struct{})
+	lifecycle.OnStart(func(context.Context) error {
+		attempts++
+		if attempts == 1 {
+			return startErr
+		}
+		close(retried)
+		return nil
+	})
+
+	waited := make(chan error, 1)
+	go func() {
+		waited <- lifecycle.WaitReady(context.Background())
+	}()
+
+	if err := lifecycle.Run(context.Background()); !errors.Is(err, startErr) {
+		t.Fatalf("Run() error = %v, want %v", err, startErr)
+	}
+	if err := <-waited; !errors.Is(err, startErr) {
+		t.Fatalf("WaitReady() error = %v, want %v", err, startErr)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- lifecycle.Run(ctx)
+	}()
+	<-retried
+	if err := lifecycle.WaitReady(context.Background()); err != nil {
+		t.Fatalf("WaitReady() after retry error = %v", err)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("retried Run() error = %v", err)
+	}
+}
+
+func TestLifecycleShutdownReturnsComponentError(t *testing.T) {
+	lifecycle := &Lifecycle{}
+	failure := errors.New("consumer crashed")
+	lifecycle.OnStart(func(context.Context) error {
+		go lifecycle.Shutdown(failure)
+		return nil
+	})
+
+	if err := lifecycle.Run(context.Background()); !errors.Is(err, failure) {
+		t.Fatalf("Run() error = %v, want %v", err, failure)
+	}
+	if err := lifecycle.Run(context.Background()); !errors.Is(err, ErrLifecycleStarted) {
+		t.Fatalf("second Run() error = %v, want ErrLifecycleStarted", err)
+	}
+}
+
+func TestLifecyclePanicsOnHooksAddedWhileRunning(t *testing.T) {
+	lifecycle := &Lifecycle{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- lifecycle.Run(ctx) }()
+	if err := lifecycle.WaitReady(ctx); err != nil {
+		t.Fatalf("WaitReady() error = %v", err)
+	}
+
+	func() {
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.Is(err, ErrLifecycleStarted) {
+				t.Fatalf("recover() = %v, want ErrLifecycleStarted", err)
+			}
+		}()
+		lifecycle.OnStop(func(context.Context) error { return nil })
+	}()
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+}
+
+func TestLifecycleStopHonorsTimeout(t *testing.T) {
+	lifecycle := &Lifecycle{}
+	lifecycle.Append(Hook{
+		Name: "slow",
+		OnStop: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		StopTimeout: 10 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := lifecycle.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Run() error = %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestLifecycleHookNameDefaultsToOrigin(t *testing.T) {
+	app := New(struct{}{})
+	app.OnStart(func(context.Context) error { return errors.New("boom") })
+
+	err := app.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "app/lifecycle_test.go:") {
+		t.Fatalf("Run() error = %v, want registration origin", err)
+	}
+}
@@ -0,0 +1,135 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+// Attribute keys of the log attributes propagated through contexts.
+const (
+	LogRequestIDKey = "request_id"
+	LogTenantKey    = "tenant"
+	LogUserIDKey    = "user_id"
+	LogTraceIDKey   = "trace_id"
+	LogSpanIDKey    = "span_id"
+	LogCommandKey   = "command"
+)
+
+type logAttrsContextKey struct{}
+
+var baseContext atomic.Pointer[context.Context]
+
+// WithLogAttrs returns a copy of ctx carrying attrs in addition to the log attributes
+// already stored in it. They are appended to every record logged with the *Context
+// methods of loggers created by NewLoggerService.
+func WithLogAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	if len(attrs) == 0 {
+		return ctx
+	}
+
+	existing := LogAttrsFromContext(ctx)
+	merged := make([]slog.Attr, 0, len(existing)+len(attrs))
+	merged = append(merged, existing...)
+	merged = append(merged, attrs...)
+
+	return context.WithValue(ctx, logAttrsContextKey{}, merged)
+}
+
+// LogAttrsFromContext returns the log attributes stored in ctx.
+func LogAttrsFromContext(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(logAttrsContextKey{}).([]slog.Attr)
+
+	return attrs
+}
+
+// WithRequestID adds the request ID to the log attributes of ctx.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return WithLogAttrs(ctx, slog.String(LogRequestIDKey, requestID))
+}
+
+// RequestIDFromContext returns the request ID stored in ctx by WithRequestID, if any.
+func RequestIDFromContext(ctx context.Context) string {
+	attrs := LogAttrsFromContext(ctx)
+	for i := len(attrs) - 1; i >= 0; i-- {
+		if attrs[i].Key == LogRequestIDKey {
+			return attrs[i].Value.String()
+		}
+	}
+
+	return ""
+}
+
+// WithTenant adds the tenant to the log attributes of ctx.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return WithLogAttrs(ctx, slog.String(LogTenantKey, tenant))
+}
+
+// WithUserID adds the user ID to the log attributes of ctx.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return WithLogAttrs(ctx, slog.String(LogUserIDKey, userID))
+}
+
+// WithTrace adds the trace and span IDs to the log attributes of ctx. Empty IDs are
+// skipped.
+func WithTrace(ctx context.Context, traceID string, spanID string) context.Context {
+	attrs := make([]slog.Attr, 0, 2)
+	if traceID != "" {
+		attrs = append(attrs, slog.String(LogTraceIDKey, traceID))
+	}
+	if spanID != "" {
+		attrs = append(attrs, slog.String(LogSpanIDKey, spanID))
+	}
+
+	return WithLogAttrs(ctx, attrs...)
+}
+
+// WithCommand adds the id of the running CLI command to the log attributes of ctx.
+func WithCommand(ctx context.Context, commandID string) context.Context {
+	return WithLogAttrs(ctx, slog.String(LogCommandKey, commandID))
+}
+
+// BaseContext returns the root context of the process, context.Background unless
+// replaced with SetBaseContext, e.g. by the CLI bootstrap to tag logs with the command.
+func BaseContext() context.Context {
+	if ctx := baseContext.Load(); ctx != nil {
+		return *ctx
+	}
+
+	return context.Background()
+}
+
+// SetBaseContext replaces the root context returned by BaseContext.
+func SetBaseContext(ctx context.Context) {
+	baseContext.Store(&ctx)
+}
+
+// contextHandler appends the log attributes stored in the record context. Like any
+// record attribute, they are qualified by the groups of the logger.
+type contextHandler struct {
+	next slog.Handler
+}
+
+func (h *contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs := LogAttrsFromContext(ctx); len(attrs) > 0 {
+		record = record.Clone()
+		record.AddAttrs(attrs...)
+	}
+
+	return h.next.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{next: h.next.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{next: h.next.WithGroup(name)}
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestContextHandlerAppendsContextAttrs(t *testing.T) {
+	output := &bytes.Buffer{}
+	logger := slog.New(&contextHandler{next: slog.NewTextHandler(output, nil)})
+
+	ctx := WithRequestID(context.Background(), "r-1")
+	ctx = WithUserID(ctx, "u-7")
+	ctx = WithTrace(ctx, "4bf92f3577b34da6a3ce929d0e0e4736", "")
+
+	logger.InfoContext(ctx, "handled", "status", 200)
+	logger.Info("without context")
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("log = %q, want two records", output.String())
+	}
+	if !strings.HasSuffix(
+		lines[0],
+		"status=200 request_id=r-1 user_id=u-7 trace_id=4bf92f3577b34da6a3ce929d0e0e4736",
+	) {
+		t.Fatalf("record = %q, want the context attributes appended", lines[0])
+	}
+	if strings.Contains(lines[1], "request_id") {
+		t.Fatalf("record = %q, want no context attributes", lines[1])
+	}
+}
+
+func TestWithLogAttrsDoesNotShareParentAttrs(t *testing.T) {
+	parent := WithRequestID(context.Background(), "r-1")
+	first := WithUserID(parent, "u-1")
+	second := WithUserID(parent, "u-2")
+
+	if len(LogAttrsFromContext(parent)) != 1 {
+		t.Fatalf("parent attrs = %v, want only the request ID", LogAttrsFromContext(parent))
+	}
+	if got := LogAttrsFromContext(first)[1].Value.String(); got != "u-1" {
+		t.Fatalf("first user = %q, want u-1", got)
+	}
+	if got := LogAttrsFromContext(second)[1].Value.String(); got != "u-2" {
+		t.Fatalf("second user = %q, want u-2", got)
+	}
+}
@@ -0,0 +1,247 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/golibry/go-web-skeleton/framework/config"
+)
+
+const (
+	ansiReset  = "\033[0m"
+	ansiDim    = "\033[2m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiBlue   = "\033[34m"
+	ansiCyan   = "\033[36m"
+)
+
+// newLogHandler creates the slog handler for a log format.
+func newLogHandler(format string, writer io.Writer, level slog.Leveler) (slog.Handler, error) {
+	handlerOptions := &slog.HandlerOptions{
+		Level: level,
+	}
+
+	switch format {
+	case "", config.LogFormatJSON:
+		return slog.NewJSONHandler(writer, handlerOptions), nil
+	case config.LogFormatText:
+		return slog.NewTextHandler(writer, handlerOptions), nil
+	case config.LogFormatPretty:
+		return newPrettyHandler(writer, level, os.Getenv("NO_COLOR") == ""), nil
+	default:
+		return nil, fmt.Errorf("unsupported log format %q", format)
+	}
+}
+
+// multiHandler fans every record out to the handlers that accept its level.
+type multiHandler struct {
+	handlers []slog.Handler
+}
+
+func newMultiHandler(handlers ...slog.Handler) slog.Handler {
+	if len(handlers) == 1 {
+		return handlers[0]
+	}
+
+	return &multiHandler{handlers: handlers}
+}
+
+func (h *multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (h *multiHandler) Handle(ctx context.Context, record slog.Record) error {
+	errs := make([]error, 0)
+	for _, handler := range h.handlers {
+		if !handler.Enabled(ctx, record.Level) {
+			continue
+		}
+		if err := handler.Handle(ctx, record.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (h *multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, 0, len(h.handlers))
+	for _, handler := range h.handlers {
+		handlers = append(handlers, handler.WithAttrs(attrs))
+	}
+
+	return &multiHandler{handlers: handlers}
+}
+
+func (h *multiHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, 0, len(h.handlers))
+	for _, handler := range h.handlers {
+		handlers = append(handlers, handler.WithGroup(name))
+	}
+
+	return &multiHandler{handlers: handlers}
+}
+
+// prettyHandler writes human-readable, optionally colored, single line records:
+//
+//	15:04:05.000 INFO  message key=value group.key=value
+type prettyHandler struct {
+	writer io.Writer
+	mu     *sync.Mutex
+	level  slog.Leveler
+	color  bool
+	attrs  []byte
+	prefix string
+}
+
+func newPrettyHandler(writer io.Writer, level slog.Leveler, color bool) *prettyHandler {
+	if level == nil {
+		level = slog.LevelInfo
+	}
+
+	return &prettyHandler{
+		writer: writer,
+		mu:     &sync.Mutex{},
+		level:  level,
+		color:  color,
+	}
+}
+
+func (h *prettyHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *prettyHandler) Handle(_ context.Context, record slog.Record) error {
+	buf := make([]byte, 0, 256)
+	if !record.Time.IsZero() {
+		buf = h.appendColored(buf, ansiDim, record.Time.Format("15:04:05.000"))
+		buf = append(buf, ' ')
+	}
+	buf = h.appendColored(buf, levelColor(record.Level), fmt.Sprintf("%-5s", record.Level))
+	buf = append(buf, ' ')
+	buf = append(buf, record.Message...)
+	buf = append(buf, h.attrs...)
+	record.Attrs(func(attr slog.Attr) bool {
+		buf = h.appendAttr(buf, h.prefix, attr)
+		return true
+	})
+	buf = append(buf, '\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.writer.Write(buf)
+
+	return err
+}
+
+func (h *prettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append([]byte(nil), h.attrs...)
+	for _, attr := range attrs {
+		clone.attrs = h.appendAttr(clone.attrs, h.prefix, attr)
+	}
+
+	return &clone
+}
+
+func (h *prettyHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+
+	return &clone
+}
+
+func (h *prettyHandler) appendAttr(buf []byte, prefix string, attr slog.Attr) []byte {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return buf
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if attr.Key != "" {
+			groupPrefix = prefix + attr.Key + "."
+		}
+		for _, groupAttr := range attr.Value.Group() {
+			buf = h.appendAttr(buf, groupPrefix, groupAttr)
+		}
+		return buf
+	}
+
+	buf = append(buf, ' ')
+	buf = h.appendColored(buf, ansiCyan, prefix+attr.Key)
+	buf = append(buf, '=')
+
+	return append(buf, formatPrettyValue(attr.Value)...)
+}
+
+func (h *prettyHandler) appendColored(buf []byte, color string, s string) []byte {
+	if !h.color {
+		return append(buf, s...)
+	}
+
+	buf = append(buf, color...)
+	buf = append(buf, s...)
+
+	return append(buf, ansiReset...)
+}
+
+func levelColor(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return ansiRed
+	case level >= slog.LevelWarn:
+		return ansiYellow
+	case level >= slog.LevelInfo:
+		return ansiGreen
+	default:
+		return ansiBlue
+	}
+}
+
+func formatPrettyValue(value slog.Value) string {
+	var s string
+	switch value.Kind() {
+	case slog.KindTime:
+		s = value.Time().Format(time.RFC3339Nano)
+	case slog.KindAny:
+		if err, ok := value.Any().(error); ok {
+			s = err.Error()
+		} else {
+			s = value.String()
+		}
+	default:
+		s = value.String()
+	}
+
+	if s == "" || strings.IndexFunc(s, needsQuoting) >= 0 {
+		return strconv.Quote(s)
+	}
+
+	return s
+}
+
+func needsQuoting(r rune) bool {
+	return unicode.IsSpace(r) || r == '"' || r == '=' || !unicode.IsPrint(r)
+}
@@ -0,0 +1,247 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// LogComponentKey is the attribute naming the component a logger belongs to. Component
+// level overrides apply to loggers carrying it, e.g. LoggerService.Named("sql").
+const LogComponentKey = "component"
+
+// lowestLogLevel lets every record reach the handlers wrapped by the level controller,
+// which makes the actual level decision.
+const lowestLogLevel = slog.Level(math.MinInt32)
+
+// levelController holds the runtime log level and the per-component overrides.
+type levelController struct {
+	base        slog.LevelVar
+	mu          sync.RWMutex
+	overrides   map[string]slog.Level
+	revertTimer *time.Timer
+	revertLevel slog.Level
+}
+
+func (c *levelController) levelFor(component string) slog.Level {
+	if component != "" {
+		c.mu.RLock()
+		level, ok := c.overrides[component]
+		c.mu.RUnlock()
+		if ok {
+			return level
+		}
+	}
+
+	return c.base.Level()
+}
+
+// minimum returns the lowest level enabled for any component.
+func (c *levelController) minimum() slog.Level {
+	minimum := c.base.Level()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, level := range c.overrides {
+		minimum = min(minimum, level)
+	}
+
+	return minimum
+}
+
+// componentLevelHandler filters records by the level configured for their component.
+type componentLevelHandler struct {
+	next      slog.Handler
+	levels    *levelController
+	component string
+	grouped   bool
+}
+
+func (h *componentLevelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.component != "" {
+		return level >= h.levels.levelFor(h.component)
+	}
+
+	// The component may still be passed with the record attributes.
+	return level >= h.levels.minimum()
+}
+
+func (h *componentLevelHandler) Handle(ctx context.Context, record slog.Record) error {
+	component := h.component
+	if component == "" && !h.grouped {
+		record.Attrs(func(attr slog.Attr) bool {
+			if attr.Key == LogComponentKey {
+				component = attr.Value.String()
+				return false
+			}
+			return true
+		})
+	}
+
+	if record.Level < h.levels.levelFor(component) {
+		return nil
+	}
+
+	return h.next.Handle(ctx, record)
+}
+
+func (h *componentLevelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	component := h.component
+	if !h.grouped {
+		for _, attr := range attrs {
+			if attr.Key == LogComponentKey {
+				component = attr.Value.String()
+			}
+		}
+	}
+
+	return &componentLevelHandler{
+		next:      h.next.WithAttrs(attrs),
+		levels:    h.levels,
+		component: component,
+		grouped:   h.grouped,
+	}
+}
+
+func (h *componentLevelHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return &componentLevelHandler{
+		next:      h.next.WithGroup(name),
+		levels:    h.levels,
+		component: h.component,
+		grouped:   true,
+	}
+}
+
+// Level returns the current level of the primary log output.
+func (l *LoggerService) Level() slog.Level {
+	return l.levels.base.Level()
+}
+
+// SetLevel changes the level of the primary log output and cancels a pending revert
+// scheduled by SetLevelFor.
+func (l *LoggerService) SetLevel(level slog.Level) {
+	l.levels.mu.Lock()
+	defer l.levels.mu.Unlock()
+
+	l.stopRevert()
+	l.levels.base.Set(level)
+}
+
+// SetLevelFor changes the level of the primary log output for duration, then reverts
+// it to the level it had before the first of consecutive SetLevelFor calls.
+func (l *LoggerService) SetLevelFor(level slog.Level, duration time.Duration) {
+	l.levels.mu.Lock()
+	defer l.levels.mu.Unlock()
+
+	revertLevel := l.levels.base.Level()
+	if l.levels.revertTimer != nil {
+		revertLevel = l.levels.revertLevel
+		l.stopRevert()
+	}
+
+	l.levels.base.Set(level)
+	l.levels.revertLevel = revertLevel
+
+	var timer *time.Timer
+	timer = time.AfterFunc(duration, func() {
+		l.levels.mu.Lock()
+		defer l.levels.mu.Unlock()
+
+		// A later SetLevel or SetLevelFor call replaced this revert.
+		if l.levels.revertTimer != timer {
+			return
+		}
+		l.levels.revertTimer = nil
+		l.levels.base.Set(revertLevel)
+	})
+	l.levels.revertTimer = timer
+}
+
+// stopRevert cancels a pending revert. The caller must hold the levels lock.
+func (l *LoggerService) stopRevert() {
+	if l.levels.revertTimer != nil {
+		l.levels.revertTimer.Stop()
+		l.levels.revertTimer = nil
+	}
+}
+
+// SetComponentLevel overrides the level for loggers of one component.
+func (l *LoggerService) SetComponentLevel(component string, level slog.Level) {
+	l.levels.mu.Lock()
+	defer l.levels.mu.Unlock()
+
+	if l.levels.overrides == nil {
+		l.levels.overrides = make(map[string]slog.Level)
+	}
+	l.levels.overrides[component] = level
+}
+
+// ResetComponentLevel removes the level override of a component.
+func (l *LoggerService) ResetComponentLevel(component string) {
+	l.levels.mu.Lock()
+	defer l.levels.mu.Unlock()
+
+	delete(l.levels.overrides, component)
+}
+
+// ComponentLevels returns a copy of the component level overrides.
+func (l *LoggerService) ComponentLevels() map[string]slog.Level {
+	l.levels.mu.RLock()
+	defer l.levels.mu.RUnlock()
+
+	overrides := make(map[string]slog.Level, len(l.levels.overrides))
+	for component, level := range l.levels.overrides {
+		overrides[component] = level
+	}
+
+	return overrides
+}
+
+// Named returns a logger tagged with a component, so component level overrides apply.
+func (l *LoggerService) Named(component string) *slog.Logger {
+	return l.logger.With(LogComponentKey, component)
+}
+
+// watchDebugSignal toggles debug logging for duration on every SIGUSR1. A signal
+// received while the temporary debug level is active reverts it right away.
+func (l *LoggerService) watchDebugSignal(duration time.Duration) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-signals:
+				l.levels.mu.RLock()
+				active := l.levels.revertTimer != nil
+				revertLevel := l.levels.revertLevel
+				l.levels.mu.RUnlock()
+
+				if active {
+					l.SetLevel(revertLevel)
+					l.logger.Warn("Debug logging disabled by signal", "level", revertLevel)
+					continue
+				}
+				l.SetLevelFor(slog.LevelDebug, duration)
+				l.logger.Warn("Debug logging enabled by signal", "duration", duration)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	l.stopSignals = func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
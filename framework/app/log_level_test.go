@@ -0,0 +1,74 @@
+package app
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLoggerService(t *testing.T, level slog.Level) (*LoggerService, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	service, err := NewLoggerService(path, level, LoggerOptions{})
+	if err != nil {
+		t.Fatalf("NewLoggerService() error = %v", err)
+	}
+	t.Cleanup(func() { _ = service.Close() })
+
+	return service, path
+}
+
+func TestLoggerServiceSetLevelAppliesAtRuntime(t *testing.T) {
+	service, path := newTestLoggerService(t, slog.LevelWarn)
+
+	service.Logger().Info("before")
+	service.SetLevel(slog.LevelInfo)
+	service.Logger().Info("after")
+
+	log, _ := os.ReadFile(path)
+	if strings.Contains(string(log), "before") || !strings.Contains(string(log), "after") {
+		t.Fatalf("log = %q, want only the record logged after SetLevel", log)
+	}
+}
+
+func TestLoggerServiceSetLevelForReverts(t *testing.T) {
+	service, _ := newTestLoggerService(t, slog.LevelWarn)
+
+	service.SetLevelFor(slog.LevelDebug, 20*time.Millisecond)
+	service.SetLevelFor(slog.LevelInfo, 20*time.Millisecond)
+	if service.Level() != slog.LevelInfo {
+		t.Fatalf("Level() = %v, want INFO", service.Level())
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for service.Level() != slog.LevelWarn {
+		if time.Now().After(deadline) {
+			t.Fatalf("Level() = %v, want the initial WARN after the duration", service.Level())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestLoggerServiceComponentLevelOverrides(t *testing.T) {
+	service, path := newTestLoggerService(t, slog.LevelWarn)
+	service.SetComponentLevel("sql", slog.LevelDebug)
+
+	service.Named("sql").Debug("query")
+	service.Logger().Debug("debug", LogComponentKey, "sql")
+	service.Named("http").Debug("request")
+	service.Logger().Info("info")
+
+	service.ResetComponentLevel("sql")
+	service.Named("sql").Debug("ignored query")
+
+	log, _ := os.ReadFile(path)
+	if strings.Count(string(log), "\n") != 2 ||
+		!strings.Contains(string(log), `"msg":"query"`) ||
+		!strings.Contains(string(log), `"msg":"debug"`) {
+		t.Fatalf("log = %q, want only the two sql debug records", log)
+	}
+}
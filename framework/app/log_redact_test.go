@@ -0,0 +1,108 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedactingHandlerMasksKeysAndValues(t *testing.T) {
+	output := &bytes.Buffer{}
+	handler := newRedactingHandler(
+		slog.NewTextHandler(output, nil),
+		RedactionOptions{Keys: append([]string{"iban"}, DefaultRedactedKeys...)},
+	)
+	logger := slog.New(handler).With("api_key", "k-123")
+
+	logger.Info(
+		"connecting to user:hunter2@tcp(localhost:3306)/app",
+		"user", "john",
+		slog.Group("request", "Authorization", "Bearer abc.def", "note", "Bearer xyz"),
+		"card", "4111 1111 1111 1111",
+		"order", "1234567890123",
+		"customer_iban", "DE89370400440532013000",
+		"error", errors.New("dial postgres://app:s3cret@db/app failed"),
+	)
+
+	log := output.String()
+	for _, secret := range []string{"k-123", "hunter2", "abc.def", "xyz", "4111", "DE89", "s3cret"} {
+		if strings.Contains(log, secret) {
+			t.Fatalf("log = %q, contains %q", log, secret)
+		}
+	}
+	for _, kept := range []string{"user=john", "order=1234567890123", "user:[REDACTED]@tcp", "request.note="} {
+		if !strings.Contains(log, kept) {
+			t.Fatalf("log = %q, want it to contain %q", log, kept)
+		}
+	}
+}
+
+func TestRedactCardNumbersChecksLuhn(t *testing.T) {
+	if _, changed := RedactCardNumbers("4111-1111-1111-1112"); changed {
+		t.Fatal("RedactCardNumbers() changed a number failing the Luhn check")
+	}
+	if value, changed := RedactCardNumbers("card 5500-0000-0000-0004 used"); !changed ||
+		value != "card "+RedactedValue+" used" {
+		t.Fatalf("RedactCardNumbers() = %q, %v", value, changed)
+	}
+}
+
+func TestRedactingHandlerMasksMapsAndHeaders(t *testing.T) {
+	output := &bytes.Buffer{}
+	logger := slog.New(newRedactingHandler(slog.NewJSONHandler(output, nil), RedactionOptions{}))
+
+	logger.Info(
+		"request",
+		"headers", http.Header{"Authorization": {"Bearer abcdef123456"}, "Accept": {"text/html"}},
+		"params", map[string]any{"note": "Bearer xyz789", "nested": map[string]string{"client_secret": "s3"}},
+		"tags", []string{"a", "Basic dXNlcjpwYXNz"},
+	)
+
+	log := output.String()
+	for _, secret := range []string{"abcdef123456", "xyz789", `"s3"`, "dXNlcjpwYXNz"} {
+		if strings.Contains(log, secret) {
+			t.Fatalf("log = %s, contains %q", log, secret)
+		}
+	}
+	for _, kept := range []string{`"Accept":["text/html"]`, `"Authorization":"[REDACTED]"`, `"tags":["a","Basic [REDACTED]"]`} {
+		if !strings.Contains(log, kept) {
+			t.Fatalf("log = %s, want it to contain %s", log, kept)
+		}
+	}
+}
+
+type redactedConfig struct {
+	Host     string `json:"host"`
+	Password string `json:"password"`
+	Database struct {
+		Dsn string
+	} `json:"database"`
+	Started time.Time `json:"started"`
+	private string
+}
+
+func TestRedactingHandlerMasksStructFields(t *testing.T) {
+	output := &bytes.Buffer{}
+	logger := slog.New(newRedactingHandler(slog.NewJSONHandler(output, nil), RedactionOptions{}))
+	config := &redactedConfig{Host: "db", Password: "hunter2", private: "p"}
+	config.Database.Dsn = "app:s3cret@tcp(db)/app"
+	config.Started = time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	logger.Info("config", "cfg", config, "plain", redactedConfig{Host: "kept"})
+
+	log := output.String()
+	for _, secret := range []string{"hunter2", "s3cret", `"p"`} {
+		if strings.Contains(log, secret) {
+			t.Fatalf("log = %s, contains %q", log, secret)
+		}
+	}
+	for _, kept := range []string{`"host":"db"`, `"password":"[REDACTED]"`, `"Dsn":"[REDACTED]"`, `"started":"2026-05-01T00:00:00Z"`} {
+		if !strings.Contains(log, kept) {
+			t.Fatalf("log = %s, want it to contain %s", log, kept)
+		}
+	}
+}
@@ -0,0 +1,429 @@
+package app
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	backupTimeFormat     = "2006-01-02T15-04-05.000"
+	compressedLogFileExt = ".gz"
+)
+
+// RotationOptions configures log file rotation. The zero value disables rotation.
+type RotationOptions struct {
+	// MaxSize is the size in bytes after which the file is rotated.
+	MaxSize int64
+
+	// MaxAge is the age after which the file is rotated.
+	MaxAge time.Duration
+
+	// MaxBackups is the number of rotated files to keep. Zero keeps all of them.
+	MaxBackups int
+
+	// Compress gzips rotated files.
+	Compress bool
+
+	// ReopenOnSighup reopens the file when the process receives SIGHUP.
+	ReopenOnSighup bool
+}
+
+// rotatingFileWriter is a file log writer that rotates the file by size and age,
+// prunes and compresses rotated files and can be reopened in place.
+type rotatingFileWriter struct {
+	path    string
+	options RotationOptions
+	now     func() time.Time
+	rename  func(oldPath, newPath string) error
+	mu      sync.Mutex
+	file    *os.File
+	closed  bool
+	size    int64
+
+	// nextRotationSize and nextRotationAt are the thresholds of the next rotation, moved
+	// by a full MaxSize and MaxAge after a failed rotation so that it is not retried on
+	// every write.
+	nextRotationSize int64
+	nextRotationAt   time.Time
+
+	millMu sync.Mutex
+	millWG sync.WaitGroup
+
+	signals chan os.Signal
+	done    chan struct{}
+}
+
+func newRotatingFileWriter(path string, options RotationOptions) (*rotatingFileWriter, error) {
+	writer := &rotatingFileWriter{
+		path:    path,
+		options: options,
+		now:     time.Now,
+		rename:  os.Rename,
+		done:    make(chan struct{}),
+	}
+	if err := writer.open(); err != nil {
+		return nil, err
+	}
+
+	if options.ReopenOnSighup {
+		writer.signals = make(chan os.Signal, 1)
+		signal.Notify(writer.signals, syscall.SIGHUP)
+		go writer.reopenOnSignal()
+	}
+
+	return writer, nil
+}
+
+func (w *rotatingFileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	// A failed rotation or reopen leaves no file, retry opening it
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if w.shouldRotate(int64(len(p))) {
+		if err := w.rotate(); err != nil {
+			if w.file == nil {
+				return 0, err
+			}
+			// The file was reopened, keep logging to it and retry on the next write
+			_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+
+	return n, err
+}
+
+// Reopen closes and reopens the log file, picking up a file moved away by an
+// external tool.
+func (w *rotatingFileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return os.ErrClosed
+	}
+	if w.file != nil {
+		err := w.file.Close()
+		w.file = nil
+		if err != nil {
+			return fmt.Errorf("failed to close log file: %w", err)
+		}
+	}
+
+	return w.open()
+}
+
+func (w *rotatingFileWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	if w.signals != nil {
+		signal.Stop(w.signals)
+	}
+	close(w.done)
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.mu.Unlock()
+
+	w.millWG.Wait()
+
+	return err
+}
+
+func (w *rotatingFileWriter) reopenOnSignal() {
+	for {
+		select {
+		case <-w.signals:
+			if err := w.Reopen(); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "failed to reopen log file: %v\n", err)
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// shouldRotate reports whether the file must be rotated before writing writeSize
+// bytes. An empty file is never rotated, its age starts with its first write. The
+// caller must hold the lock.
+func (w *rotatingFileWriter) shouldRotate(writeSize int64) bool {
+	if w.size == 0 {
+		w.nextRotationAt = w.now().Add(w.options.MaxAge)
+		return false
+	}
+	if w.options.MaxSize > 0 && w.size+writeSize > w.nextRotationSize {
+		return true
+	}
+
+	return w.options.MaxAge > 0 && !w.now().Before(w.nextRotationAt)
+}
+
+// open opens the log file for appending. The caller must hold the lock.
+func (w *rotatingFileWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	w.file = file
+	w.size = info.Size()
+	w.nextRotationSize = w.options.MaxSize
+	w.nextRotationAt = w.startedAt(info).Add(w.options.MaxAge)
+
+	return nil
+}
+
+// startedAt returns when the log file was started, so that restarts and reopens do not
+// reset its age: the time of the latest rotation, the modification time of a file
+// without rotated files and the current time of a new file.
+func (w *rotatingFileWriter) startedAt(info os.FileInfo) time.Time {
+	if info.Size() == 0 {
+		return w.now()
+	}
+
+	backups, err := w.backups()
+	if err == nil && len(backups) > 0 {
+		return backups[0].rotatedAt
+	}
+
+	return info.ModTime()
+}
+
+// rotate moves the current file to a timestamped backup and opens a new one. When
+// the file cannot be moved, it is reopened and written to until the next size or age
+// threshold. When no file can be opened, the next write retries. The caller must hold
+// the lock.
+func (w *rotatingFileWriter) rotate() error {
+	err := w.file.Close()
+	w.file = nil
+	if err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+
+	now := w.now()
+	if err := w.rename(w.path, w.freeBackupPath(now)); err != nil {
+		if openErr := w.open(); openErr != nil {
+			return errors.Join(fmt.Errorf("failed to rotate log file: %w", err), openErr)
+		}
+		w.nextRotationSize = w.size + w.options.MaxSize
+		w.nextRotationAt = now.Add(w.options.MaxAge)
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	w.millWG.Add(1)
+	go func() {
+		defer w.millWG.Done()
+		w.mill()
+	}()
+
+	return nil
+}
+
+func (w *rotatingFileWriter) backupPath(at time.Time, sequence int) string {
+	dir := filepath.Dir(w.path)
+	ext := filepath.Ext(w.path)
+	prefix := strings.TrimSuffix(filepath.Base(w.path), ext)
+	timestamp := at.UTC().Format(backupTimeFormat)
+	if sequence > 0 {
+		timestamp += "-" + strconv.Itoa(sequence)
+	}
+
+	return filepath.Join(dir, fmt.Sprintf("%s-%s%s", prefix, timestamp, ext))
+}
+
+// freeBackupPath returns the backup path for a rotation at the given time, numbered
+// when a rotated or compressed file of the same millisecond exists already.
+func (w *rotatingFileWriter) freeBackupPath(at time.Time) string {
+	for sequence := 0; ; sequence++ {
+		path := w.backupPath(at, sequence)
+		if !pathExists(path) && !pathExists(path+compressedLogFileExt) {
+			return path
+		}
+	}
+}
+
+func pathExists(path string) bool {
+	_, err := os.Lstat(path)
+	return !errors.Is(err, os.ErrNotExist)
+}
+
+// mill compresses and prunes rotated files.
+func (w *rotatingFileWriter) mill() {
+	w.millMu.Lock()
+	defer w.millMu.Unlock()
+
+	backups, err := w.backups()
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "failed to list rotated log files: %v\n", err)
+		return
+	}
+
+	if w.options.MaxBackups > 0 && len(backups) > w.options.MaxBackups {
+		for _, backup := range backups[w.options.MaxBackups:] {
+			if err := os.Remove(backup.path); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "failed to remove rotated log file: %v\n", err)
+			}
+		}
+		backups = backups[:w.options.MaxBackups]
+	}
+
+	if !w.options.Compress {
+		return
+	}
+	for _, backup := range backups {
+		if strings.HasSuffix(backup.path, compressedLogFileExt) {
+			continue
+		}
+		if err := compressLogFile(backup.path); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "failed to compress rotated log file: %v\n", err)
+		}
+	}
+}
+
+type logBackup struct {
+	path      string
+	rotatedAt time.Time
+	sequence  int
+}
+
+// backups returns the rotated files of the log, newest first.
+func (w *rotatingFileWriter) backups() ([]logBackup, error) {
+	dir := filepath.Dir(w.path)
+	ext := filepath.Ext(w.path)
+	prefix := strings.TrimSuffix(filepath.Base(w.path), ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make([]logBackup, 0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		timestamp := strings.TrimPrefix(name, prefix)
+		timestamp = strings.TrimSuffix(timestamp, compressedLogFileExt)
+		timestamp = strings.TrimSuffix(timestamp, ext)
+		rotatedAt, sequence, ok := parseBackupTimestamp(timestamp)
+		if !ok {
+			continue
+		}
+
+		backups = append(backups, logBackup{
+			path:      filepath.Join(dir, name),
+			rotatedAt: rotatedAt,
+			sequence:  sequence,
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].rotatedAt.Equal(backups[j].rotatedAt) {
+			return backups[i].sequence > backups[j].sequence
+		}
+		return backups[i].rotatedAt.After(backups[j].rotatedAt)
+	})
+
+	return backups, nil
+}
+
+// parseBackupTimestamp parses the rotation time and the optional sequence number of a
+// backup, e.g. "2026-01-02T03-04-05.000-1".
+func parseBackupTimestamp(timestamp string) (time.Time, int, bool) {
+	if len(timestamp) < len(backupTimeFormat) {
+		return time.Time{}, 0, false
+	}
+
+	rotatedAt, err := time.Parse(backupTimeFormat, timestamp[:len(backupTimeFormat)])
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+
+	rest := timestamp[len(backupTimeFormat):]
+	if rest == "" {
+		return rotatedAt, 0, true
+	}
+	sequence, err := strconv.Atoi(strings.TrimPrefix(rest, "-"))
+	if !strings.HasPrefix(rest, "-") || err != nil || sequence < 1 {
+		return time.Time{}, 0, false
+	}
+
+	return rotatedAt, sequence, true
+}
+
+func compressLogFile(path string) (err error) {
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = source.Close() }()
+
+	target, err := os.OpenFile(
+		path+compressedLogFileExt,
+		os.O_CREATE|os.O_TRUNC|os.O_WRONLY,
+		0644,
+	)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = target.Close()
+			_ = os.Remove(path + compressedLogFileExt)
+		}
+	}()
+
+	gzipWriter := gzip.NewWriter(target)
+	if _, err = io.Copy(gzipWriter, source); err != nil {
+		return err
+	}
+	if err = gzipWriter.Close(); err != nil {
+		return err
+	}
+	if err = target.Close(); err != nil {
+		return err
+	}
+	_ = source.Close()
+
+	return os.Remove(path)
+}
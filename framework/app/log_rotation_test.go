@@ -0,0 +1,292 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileWriterRotatesBySizeAndPrunesBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	writer, err := newRotatingFileWriter(path, RotationOptions{MaxSize: 10, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("newRotatingFileWriter() error = %v", err)
+	}
+	writer.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	for _, line := range []string{"first-line\n", "second-line\n", "third-line\n", "fourth-line\n"} {
+		if _, err := writer.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(current) != "fourth-line\n" {
+		t.Fatalf("current log = %q, want last line", current)
+	}
+
+	backups, err := writer.backups()
+	if err != nil {
+		t.Fatalf("backups() error = %v", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("len(backups) = %d, want 2", len(backups))
+	}
+
+	newest, err := os.ReadFile(backups[0].path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(newest) != "third-line\n" {
+		t.Fatalf("newest backup = %q, want third line", newest)
+	}
+}
+
+func TestRotatingFileWriterRotatesByAgeAndCompresses(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	writer, err := newRotatingFileWriter(path, RotationOptions{MaxAge: time.Hour, Compress: true})
+	if err != nil {
+		t.Fatalf("newRotatingFileWriter() error = %v", err)
+	}
+	writer.now = func() time.Time { return now }
+	writer.nextRotationAt = now.Add(time.Hour)
+
+	_, _ = writer.Write([]byte("before\n"))
+	now = now.Add(2 * time.Hour)
+	_, _ = writer.Write([]byte("after\n"))
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	backups, err := writer.backups()
+	if err != nil {
+		t.Fatalf("backups() error = %v", err)
+	}
+	if len(backups) != 1 || !strings.HasSuffix(backups[0].path, ".log.gz") {
+		t.Fatalf("backups = %+v, want one compressed backup", backups)
+	}
+}
+
+func TestRotatingFileWriterDoesNotRotateAnEmptyFileByAge(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	writer, err := newRotatingFileWriter(path, RotationOptions{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatalf("newRotatingFileWriter() error = %v", err)
+	}
+	writer.now = func() time.Time { return now }
+	writer.nextRotationAt = now.Add(time.Hour)
+
+	// The idle file is older than MaxAge but empty, its age starts with the first line
+	now = now.Add(2 * time.Hour)
+	_, _ = writer.Write([]byte("first\n"))
+	now = now.Add(30 * time.Minute)
+	_, _ = writer.Write([]byte("second\n"))
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	backups, err := writer.backups()
+	if err != nil {
+		t.Fatalf("backups() error = %v", err)
+	}
+	current, _ := os.ReadFile(path)
+	if len(backups) != 0 || string(current) != "first\nsecond\n" {
+		t.Fatalf("backups = %+v, current log = %q, want the empty file not rotated", backups, current)
+	}
+}
+
+func TestRotatingFileWriterReopensMovedFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	writer, err := newRotatingFileWriter(path, RotationOptions{})
+	if err != nil {
+		t.Fatalf("newRotatingFileWriter() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	_, _ = writer.Write([]byte("old\n"))
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	if err := writer.Reopen(); err != nil {
+		t.Fatalf("Reopen() error = %v", err)
+	}
+	_, _ = writer.Write([]byte("new\n"))
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(current) != "new\n" {
+		t.Fatalf("current log = %q, want new", current)
+	}
+}
+
+func TestRotatingFileWriterBacksOffAfterFailedRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	writer, err := newRotatingFileWriter(path, RotationOptions{MaxSize: 20})
+	if err != nil {
+		t.Fatalf("newRotatingFileWriter() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	renames := 0
+	writer.rename = func(string, string) error {
+		renames++
+		return os.ErrPermission
+	}
+
+	for _, line := range []string{"first-line\n", "second-line\n", "third\n", "fourth-line\n"} {
+		if _, err := writer.Write([]byte(line)); err != nil {
+			t.Fatalf("Write(%q) error = %v", line, err)
+		}
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(current) != "first-line\nsecond-line\nthird\nfourth-line\n" {
+		t.Fatalf("current log = %q, want every line in the unrotated file", current)
+	}
+	// The second line fails the rotation, the third stays below the next size threshold
+	// and the fourth crosses it
+	if renames != 2 {
+		t.Fatalf("renames = %d, want 2", renames)
+	}
+
+	writer.rename = os.Rename
+	if _, err := writer.Write([]byte("fifth-line\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	current, _ = os.ReadFile(path)
+	if string(current) != "fifth-line\n" {
+		t.Fatalf("current log = %q, want the rotation retried", current)
+	}
+}
+
+func TestRotatingFileWriterMeasuresAgeFromTheLastRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(path, []byte("before restart\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	rotatedAt := time.Now().Add(-2 * time.Hour)
+
+	probe := &rotatingFileWriter{path: path}
+	if err := os.WriteFile(probe.backupPath(rotatedAt, 0), []byte("rotated\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	// The restarted writer rotates the file started two hours ago
+	writer, err := newRotatingFileWriter(path, RotationOptions{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatalf("newRotatingFileWriter() error = %v", err)
+	}
+	if _, err := writer.Write([]byte("after restart\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	current, _ := os.ReadFile(path)
+	if string(current) != "after restart\n" {
+		t.Fatalf("current log = %q, want the file rotated by age", current)
+	}
+}
+
+func TestRotatingFileWriterNumbersBackupsOfTheSameMillisecond(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	writer, err := newRotatingFileWriter(path, RotationOptions{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("newRotatingFileWriter() error = %v", err)
+	}
+	writer.now = func() time.Time { return now }
+
+	for _, line := range []string{"first-line\n", "second-line\n", "third-line\n"} {
+		if _, err := writer.Write([]byte(line)); err != nil {
+			t.Fatalf("Write(%q) error = %v", line, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	backups, err := writer.backups()
+	if err != nil {
+		t.Fatalf("backups() error = %v", err)
+	}
+	if len(backups) != 2 || backups[0].path != writer.backupPath(now, 1) {
+		t.Fatalf("backups = %+v, want two backups, the numbered one first", backups)
+	}
+
+	newest, _ := os.ReadFile(backups[0].path)
+	oldest, _ := os.ReadFile(backups[1].path)
+	if string(newest) != "second-line\n" || string(oldest) != "first-line\n" {
+		t.Fatalf("backups = %q, %q, want no backup overwritten", newest, oldest)
+	}
+}
+
+func TestRotatingFileWriterRetriesOpenAfterFailedReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "logs", "app.log")
+	if err := os.Mkdir(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Mkdir() error = %v", err)
+	}
+
+	writer, err := newRotatingFileWriter(path, RotationOptions{})
+	if err != nil {
+		t.Fatalf("newRotatingFileWriter() error = %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	if err := os.Rename(filepath.Dir(path), filepath.Join(dir, "moved")); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+	if err := writer.Reopen(); err == nil {
+		t.Fatal("Reopen() error = nil, want the missing directory reported")
+	}
+	if _, err := writer.Write([]byte("lost\n")); err == nil {
+		t.Fatal("Write() error = nil, want the missing directory reported")
+	}
+
+	if err := os.Mkdir(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Mkdir() error = %v", err)
+	}
+	if _, err := writer.Write([]byte("back\n")); err != nil {
+		t.Fatalf("Write() error = %v, want the file opened again", err)
+	}
+	current, _ := os.ReadFile(path)
+	if string(current) != "back\n" {
+		t.Fatalf("current log = %q, want back", current)
+	}
+}
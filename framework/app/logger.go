@@ -1,11 +1,15 @@
 package app
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"time"
+
+	"github.com/golibry/go-web-skeleton/framework/config"
 )
 
 // LogWriter defines the interface for log writers that can be closed
@@ -15,13 +19,31 @@ type LogWriter interface {
 }
 
 type LoggerService struct {
-	logger    *slog.Logger
-	logWriter LogWriter
+	logger      *slog.Logger
+	logWriters  []LogWriter
+	levels      *levelController
+	stopSignals func()
 	io.Closer
 }
 
 type LoggerOptions struct {
 	SetDefault bool
+
+	// Format is the format of the logPath output: "json" (default), "text" or "pretty".
+	Format string
+
+	// Sinks are additional outputs, each with its own path, format and minimum level.
+	Sinks []config.LogSink
+
+	// Rotation configures rotation when logging to a file.
+	Rotation RotationOptions
+
+	// DebugSignalDuration enables switching the primary output to debug for this long
+	// on SIGUSR1. Zero leaves SIGUSR1 alone.
+	DebugSignalDuration time.Duration
+
+	// Redaction masks sensitive attributes and values in every output when set.
+	Redaction *RedactionOptions
 }
 
 // NewLoggerService creates a new logger service
@@ -32,38 +54,74 @@ type LoggerOptions struct {
 // - a file path to log to a file
 //
 // logLevel is the log level to use
+//
+// logLevel is only the initial level of the primary output, it can be changed at
+// runtime with SetLevel and overridden per component with SetComponentLevel.
+//
+// Records are also fanned out to the sinks from the options. Sinks sharing a path
+// share one writer, and all writers are closed together by Close.
 func NewLoggerService(
 	logPath string,
 	logLevel slog.Level,
 	options ...LoggerOptions,
 ) (*LoggerService, error) {
-	logWriter, err := createLogWriter(logPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create log writer: %w", err)
+	loggerOptions := LoggerOptions{SetDefault: true}
+	if len(options) > 0 {
+		loggerOptions = options[0]
 	}
 
-	logger := slog.New(
-		slog.NewJSONHandler(
-			logWriter,
-			&slog.HandlerOptions{
-				Level: logLevel,
-			},
-		),
+	sinks := append(
+		[]config.LogSink{{Path: logPath, Format: loggerOptions.Format, Level: logLevel}},
+		loggerOptions.Sinks...,
 	)
 
-	loggerOptions := LoggerOptions{SetDefault: true}
-	if len(options) > 0 {
-		loggerOptions = options[0]
+	service := &LoggerService{levels: &levelController{}}
+	service.levels.base.Set(logLevel)
+	writers := make(map[string]LogWriter)
+	handlers := make([]slog.Handler, 0, len(sinks))
+	for i, sink := range sinks {
+		logWriter, ok := writers[sink.Path]
+		if !ok {
+			var err error
+			logWriter, err = createLogWriter(sink.Path, loggerOptions.Rotation)
+			if err != nil {
+				_ = service.Close()
+				return nil, fmt.Errorf("failed to create log writer: %w", err)
+			}
+			writers[sink.Path] = logWriter
+			service.logWriters = append(service.logWriters, logWriter)
+		}
+
+		// The primary output level is decided at runtime by the level controller
+		var level slog.Leveler = sink.Level
+		if i == 0 {
+			level = lowestLogLevel
+		}
+		handler, err := newLogHandler(sink.Format, logWriter, level)
+		if err != nil {
+			_ = service.Close()
+			return nil, fmt.Errorf("failed to create log handler: %w", err)
+		}
+		if i == 0 {
+			handler = &componentLevelHandler{next: handler, levels: service.levels}
+		}
+		handlers = append(handlers, handler)
+	}
+
+	handler := newMultiHandler(handlers...)
+	if loggerOptions.Redaction != nil {
+		handler = newRedactingHandler(handler, *loggerOptions.Redaction)
+	}
+	service.logger = slog.New(&contextHandler{next: handler})
+	if loggerOptions.DebugSignalDuration > 0 {
+		service.watchDebugSignal(loggerOptions.DebugSignalDuration)
 	}
 
 	if loggerOptions.SetDefault {
-		slog.SetDefault(logger)
+		slog.SetDefault(service.logger)
 	}
 
-	return &LoggerService{
-		logger:    logger,
-		logWriter: logWriter,
-	}, nil
+	return service, nil
 }
 
 func (l *LoggerService) Logger() *slog.Logger {
@@ -71,26 +129,41 @@ func (l *LoggerService) Logger() *slog.Logger {
 }
 
 func (l *LoggerService) Close() error {
-	if l.logWriter != nil {
-		return l.logWriter.Close()
+	if l.stopSignals != nil {
+		l.stopSignals()
+		l.stopSignals = nil
 	}
-	return nil
+	if l.levels != nil {
+		l.levels.mu.Lock()
+		l.stopRevert()
+		l.levels.mu.Unlock()
+	}
+
+	errs := make([]error, 0)
+	for _, logWriter := range l.logWriters {
+		if err := logWriter.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	l.logWriters = nil
+
+	return errors.Join(errs...)
 }
 
 // createLogWriter creates the appropriate log writer based on the log path
-func createLogWriter(logPath string) (LogWriter, error) {
+func createLogWriter(logPath string, rotation RotationOptions) (LogWriter, error) {
 	switch logPath {
 	case "stdout":
 		return &stdoutWriter{}, nil
 	case "stderr":
 		return &stderrWriter{}, nil
 	default:
-		return createFileLogWriter(logPath)
+		return createFileLogWriter(logPath, rotation)
 	}
 }
 
 // createFileLogWriter creates a file-based log writer with proper validation and security
-func createFileLogWriter(logPath string) (LogWriter, error) {
+func createFileLogWriter(logPath string, rotation RotationOptions) (LogWriter, error) {
 	if logPath == "" {
 		return nil, fmt.Errorf("log path cannot be empty")
 	}
@@ -110,17 +183,8 @@ func createFileLogWriter(logPath string) (LogWriter, error) {
 		return nil, fmt.Errorf("failed to create log directory: %w", err)
 	}
 
-	// Open a file with appropriate permissions
-	file, err := os.OpenFile(
-		cleanPath,
-		os.O_APPEND|os.O_CREATE|os.O_WRONLY,
-		0644,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open log file: %w", err)
-	}
-
-	return &fileLogWriter{File: file}, nil
+	// Open a file with appropriate permissions, rotated according to the options
+	return newRotatingFileWriter(cleanPath, rotation)
 }
 
 // validateLogPath performs basic security validation on log file paths
@@ -138,11 +202,6 @@ func validateLogPath(path string) error {
 	return nil
 }
 
-// fileLogWriter wraps os.File to implement LogWriter interface
-type fileLogWriter struct {
-	*os.File
-}
-
 // stdoutWriter wraps os.Stdout to implement LogWriter interface
 type stdoutWriter struct{}
 
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golibry/go-web-skeleton/framework/config"
+)
+
+func TestLoggerServiceFansOutToSinksByLevel(t *testing.T) {
+	dir := t.TempDir()
+	mainPath := filepath.Join(dir, "app.log")
+	errorPath := filepath.Join(dir, "errors.log")
+
+	service, err := NewLoggerService(
+		mainPath,
+		slog.LevelInfo,
+		LoggerOptions{
+			Format: config.LogFormatJSON,
+			Sinks: []config.LogSink{
+				{Path: errorPath, Format: config.LogFormatText, Level: slog.LevelError},
+			},
+		},
+	)
+	if err != nil {
+		t.Fatalf("NewLoggerService() error = %v", err)
+	}
+
+	service.Logger().Info("started")
+	service.Logger().Error("failed", "component", "sql")
+	if err := service.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	mainLog, _ := os.ReadFile(mainPath)
+	errorLog, _ := os.ReadFile(errorPath)
+
+	if strings.Count(string(mainLog), "\n") != 2 || !strings.Contains(string(mainLog), `"msg":"started"`) {
+		t.Fatalf("main log = %q, want both JSON records", mainLog)
+	}
+	if strings.Contains(string(errorLog), "started") ||
+		!strings.Contains(string(errorLog), "msg=failed component=sql") {
+		t.Fatalf("error log = %q, want only the text error record", errorLog)
+	}
+}
+
+func TestLoggerServiceRejectsUnknownFormat(t *testing.T) {
+	_, err := NewLoggerService("stdout", slog.LevelInfo, LoggerOptions{Format: "xml"})
+	if err == nil {
+		t.Fatal("NewLoggerService() error = nil, want unsupported format error")
+	}
+}
+
+func TestPrettyHandlerWritesReadableRecords(t *testing.T) {
+	output := &bytes.Buffer{}
+	logger := slog.New(newPrettyHandler(output, slog.LevelDebug, false))
+
+	logger.With("request_id", "r-1").WithGroup("db").Info(
+		"query done",
+		"rows", 3,
+		"query", "select 1",
+	)
+
+	line := output.String()
+	expected := `INFO  query done request_id=r-1 db.rows=3 db.query="select 1"` + "\n"
+	if !strings.HasSuffix(line, expected) {
+		t.Fatalf("pretty output = %q, want suffix %q", line, expected)
+	}
+}
@@ -0,0 +1,360 @@
+package app
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Encoder writes values in one media type for ResponseBuilder.Negotiate.
+type Encoder interface {
+	// ContentType is the Content-Type header of the responses, e.g. "application/json".
+	ContentType() string
+
+	// CanEncode reports whether v can be written, e.g. CSV only writes slices of structs.
+	CanEncode(v any) bool
+
+	Encode(w io.Writer, v any) error
+}
+
+// RequestEncoder is implemented by encoders using the request to write values, e.g. to
+// expose its CSRF token to templates. Negotiate calls EncodeRequest instead of Encode.
+type RequestEncoder interface {
+	Encoder
+	EncodeRequest(w io.Writer, r *http.Request, v any) error
+}
+
+// TemplateNamer is implemented by values rendered by the TemplateEngine encoder, naming
+// their page.
+type TemplateNamer interface {
+	TemplateName() string
+}
+
+// DefaultEncoders returns the JSON, XML, plain text and CSV encoders, in the order of
+// preference used when the client accepts several of them equally.
+func DefaultEncoders() []Encoder {
+	return []Encoder{jsonEncoder{}, xmlEncoder{}, textEncoder{}, csvEncoder{}}
+}
+
+// RegisterEncoder adds encoder to the encoders of Negotiate, replacing the encoder of
+// the same media type. New media types are the least preferred.
+func (rbs *ResponseBuilder) RegisterEncoder(encoder Encoder) {
+	rbs.encodersMu.Lock()
+	defer rbs.encodersMu.Unlock()
+
+	mediaType := encoderMediaType(encoder)
+	encoders := slices.Clone(rbs.encoders)
+	for i, existing := range encoders {
+		if encoderMediaType(existing) == mediaType {
+			encoders[i] = encoder
+			rbs.encoders = encoders
+			return
+		}
+	}
+	rbs.encoders = append(encoders, encoder)
+}
+
+// Negotiate writes v with status in the media type preferred by the Accept header of
+// the request, among the registered encoders able to encode v. Requests without Accept
+// get the first of them. When none is acceptable, a 406 Not Acceptable problem listing
+// the available media types is written instead.
+func (rbs *ResponseBuilder) Negotiate(w http.ResponseWriter, r *http.Request, status int, v any) error {
+	rbs.encodersMu.RLock()
+	encoders := rbs.encoders
+	rbs.encodersMu.RUnlock()
+
+	AddVary(w, "Accept")
+
+	candidates := make([]Encoder, 0, len(encoders))
+	for _, encoder := range encoders {
+		if encoder.CanEncode(v) {
+			candidates = append(candidates, encoder)
+		}
+	}
+
+	encoder := negotiateEncoder(r.Header.Values("Accept"), candidates)
+	if encoder == nil {
+		available := make([]string, 0, len(candidates))
+		for _, candidate := range candidates {
+			available = append(available, encoderMediaType(candidate))
+		}
+		return rbs.WriteProblem(w, Problem{
+			Type:      "about:blank",
+			Title:     http.StatusText(http.StatusNotAcceptable),
+			Status:    http.StatusNotAcceptable,
+			Detail:    "Available media types: " + strings.Join(available, ", "),
+			Instance:  r.URL.Path,
+			RequestID: RequestIDFromContext(r.Context()),
+		})
+	}
+
+	buf := &bytes.Buffer{}
+	var err error
+	if requestEncoder, ok := encoder.(RequestEncoder); ok {
+		err = requestEncoder.EncodeRequest(buf, r, v)
+	} else {
+		err = encoder.Encode(buf, v)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode %s response: %w", encoderMediaType(encoder), err)
+	}
+
+	w.Header().Set("Content-Type", encoder.ContentType())
+	w.WriteHeader(status)
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// acceptRange is a media range of an Accept header.
+type acceptRange struct {
+	typ     string
+	subtype string
+	quality float64
+}
+
+// negotiateEncoder returns the encoder with the highest quality in the Accept header,
+// preferring the earliest on ties, or nil when none is acceptable.
+func negotiateEncoder(accept []string, encoders []Encoder) Encoder {
+	ranges := parseAccept(accept)
+	if len(ranges) == 0 {
+		if len(encoders) == 0 {
+			return nil
+		}
+		return encoders[0]
+	}
+
+	var (
+		best        Encoder
+		bestQuality float64
+	)
+	for _, encoder := range encoders {
+		if quality := acceptQuality(ranges, encoderMediaType(encoder)); quality > bestQuality {
+			best, bestQuality = encoder, quality
+		}
+	}
+
+	return best
+}
+
+func parseAccept(accept []string) []acceptRange {
+	var ranges []acceptRange
+	for _, header := range accept {
+		for _, value := range strings.Split(header, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(value))
+			if err != nil {
+				continue
+			}
+			typ, subtype, ok := strings.Cut(mediaType, "/")
+			if !ok {
+				continue
+			}
+
+			quality := 1.0
+			if q, ok := params["q"]; ok {
+				if quality, err = strconv.ParseFloat(q, 64); err != nil || quality < 0 || quality > 1 {
+					continue
+				}
+			}
+			ranges = append(ranges, acceptRange{typ: typ, subtype: subtype, quality: quality})
+		}
+	}
+
+	return ranges
+}
+
+// acceptQuality returns the quality of the most specific range matching mediaType.
+func acceptQuality(ranges []acceptRange, mediaType string) float64 {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+
+	quality, specificity := 0.0, -1
+	for _, r := range ranges {
+		var s int
+		switch {
+		case r.typ == typ && r.subtype == subtype:
+			s = 2
+		case r.typ == typ && r.subtype == "*":
+			s = 1
+		case r.typ == "*" && r.subtype == "*":
+			s = 0
+		default:
+			continue
+		}
+		if s > specificity {
+			quality, specificity = r.quality, s
+		}
+	}
+
+	return quality
+}
+
+func encoderMediaType(encoder Encoder) string {
+	mediaType, _, err := mime.ParseMediaType(encoder.ContentType())
+	if err != nil {
+		return encoder.ContentType()
+	}
+
+	return mediaType
+}
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) ContentType() string { return "application/json" }
+
+func (jsonEncoder) CanEncode(any) bool { return true }
+
+func (jsonEncoder) Encode(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+type xmlEncoder struct{}
+
+func (xmlEncoder) ContentType() string { return "application/xml; charset=utf-8" }
+
+// CanEncode rejects nil values, which encode to an empty document, maps, which
+// encoding/xml does not support, and slices and arrays, which encode to one root
+// element per item. Wrap collections in a struct naming the root element instead.
+func (xmlEncoder) CanEncode(v any) bool {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return false
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Invalid, reflect.Map, reflect.Slice, reflect.Array:
+		return false
+	default:
+		return true
+	}
+}
+
+func (xmlEncoder) Encode(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(v)
+}
+
+type textEncoder struct{}
+
+func (textEncoder) ContentType() string { return "text/plain; charset=utf-8" }
+
+func (textEncoder) CanEncode(v any) bool {
+	switch v.(type) {
+	case string, []byte, fmt.Stringer, error:
+		return true
+	default:
+		return false
+	}
+}
+
+func (textEncoder) Encode(w io.Writer, v any) error {
+	var err error
+	switch value := v.(type) {
+	case []byte:
+		_, err = w.Write(value)
+	default:
+		_, err = fmt.Fprint(w, value)
+	}
+	return err
+}
+
+// csvEncoder writes slices of structs, with a header row of the csv tags or the names
+// of the exported fields.
+type csvEncoder struct{}
+
+func (csvEncoder) ContentType() string { return "text/csv; charset=utf-8" }
+
+func (csvEncoder) CanEncode(v any) bool {
+	if v == nil {
+		return false
+	}
+	typ := reflect.TypeOf(v)
+	if typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array {
+		return false
+	}
+	elem := typ.Elem()
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+
+	return elem.Kind() == reflect.Struct
+}
+
+func (csvEncoder) Encode(w io.Writer, v any) error {
+	rows := reflect.ValueOf(v)
+	elem := rows.Type().Elem()
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+
+	var (
+		header  []string
+		indexes []int
+	)
+	for i := range elem.NumField() {
+		field := elem.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("csv"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		header = append(header, name)
+		indexes = append(indexes, i)
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	record := make([]string, len(indexes))
+	for i := range rows.Len() {
+		row := rows.Index(i)
+		if row.Kind() == reflect.Pointer {
+			if row.IsNil() {
+				continue
+			}
+			row = row.Elem()
+		}
+		for j, index := range indexes {
+			record[j] = csvValue(row.Field(index))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func csvValue(value reflect.Value) string {
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+	if marshaler, ok := value.Interface().(encoding.TextMarshaler); ok {
+		text, err := marshaler.MarshalText()
+		if err == nil {
+			return string(text)
+		}
+	}
+
+	return fmt.Sprint(value.Interface())
+}
@@ -0,0 +1,146 @@
+package app
+
+import (
+	"encoding/xml"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+type negotiateOrder struct {
+	ID    int     `json:"id" csv:"id"`
+	Total float64 `json:"total" csv:"total"`
+	Notes *string `json:"-" csv:"notes"`
+}
+
+type negotiatePage struct {
+	Title string
+}
+
+func (negotiatePage) TemplateName() string { return "page" }
+
+func negotiate(t *testing.T, builder *ResponseBuilder, accept string, v any) *httptest.ResponseRecorder {
+	t.Helper()
+
+	request := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	if accept != "" {
+		request.Header.Set("Accept", accept)
+	}
+	recorder := httptest.NewRecorder()
+	if err := builder.Negotiate(recorder, request, http.StatusOK, v); err != nil {
+		t.Fatalf("Negotiate() error = %v", err)
+	}
+
+	return recorder
+}
+
+func TestNegotiateHonoursAcceptQuality(t *testing.T) {
+	builder := NewResponseBuilderService(slog.New(slog.DiscardHandler), nil)
+	notes := "gift"
+	orders := []negotiateOrder{{ID: 1, Total: 9.5, Notes: &notes}, {ID: 2, Total: 3}}
+
+	tests := []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{accept: "", contentType: "application/json", body: `[{"id":1,"total":9.5},{"id":2,"total":3}]`},
+		{accept: "text/csv", contentType: "text/csv; charset=utf-8", body: "id,total,notes\n1,9.5,gift\n2,3,\n"},
+		{accept: "application/json;q=0.5, text/*;q=0.8", contentType: "text/csv; charset=utf-8"},
+		{accept: "application/*, */*;q=0.1", contentType: "application/json"},
+	}
+
+	for _, tt := range tests {
+		recorder := negotiate(t, builder, tt.accept, orders)
+
+		if got := recorder.Header().Get("Content-Type"); got != tt.contentType {
+			t.Errorf("Accept %q: Content-Type = %q, want %q", tt.accept, got, tt.contentType)
+		}
+		if tt.body != "" && strings.TrimSpace(recorder.Body.String()) != strings.TrimSpace(tt.body) {
+			t.Errorf("Accept %q: body = %q, want %q", tt.accept, recorder.Body.String(), tt.body)
+		}
+		if recorder.Header().Get("Vary") != "Accept" {
+			t.Errorf("Accept %q: Vary = %q, want Accept", tt.accept, recorder.Header().Get("Vary"))
+		}
+	}
+}
+
+func TestNegotiateRejectsUnacceptableRequests(t *testing.T) {
+	builder := NewResponseBuilderService(slog.New(slog.DiscardHandler), nil)
+
+	recorder := negotiate(t, builder, "text/csv, application/json;q=0", map[string]int{"total": 1})
+
+	if recorder.Code != http.StatusNotAcceptable {
+		t.Fatalf("status = %d, want 406", recorder.Code)
+	}
+	if !strings.Contains(recorder.Body.String(), "application/json") {
+		t.Fatalf("body = %q, want the available media types", recorder.Body.String())
+	}
+}
+
+type negotiateOrders struct {
+	XMLName xml.Name         `xml:"orders"`
+	Orders  []negotiateOrder `xml:"order"`
+}
+
+func TestNegotiateWritesXMLWithOneRootElement(t *testing.T) {
+	builder := NewResponseBuilderService(slog.New(slog.DiscardHandler), nil)
+	orders := []negotiateOrder{{ID: 1, Total: 9.5}, {ID: 2, Total: 3}}
+
+	recorder := negotiate(t, builder, "application/xml", negotiateOrders{Orders: orders})
+	want := xml.Header + "<orders><order><ID>1</ID><Total>9.5</Total></order>" +
+		"<order><ID>2</ID><Total>3</Total></order></orders>"
+	if recorder.Header().Get("Content-Type") != "application/xml; charset=utf-8" ||
+		recorder.Body.String() != want {
+		t.Fatalf("body = %q, want %q", recorder.Body.String(), want)
+	}
+
+	var missing *negotiateOrders
+	for _, v := range []any{orders, [1]negotiateOrder{}, missing, nil} {
+		if recorder := negotiate(t, builder, "application/xml", v); recorder.Code != http.StatusNotAcceptable {
+			t.Errorf("Negotiate(%#v) status = %d, want 406", v, recorder.Code)
+		}
+	}
+}
+
+type upperEncoder struct{}
+
+func (upperEncoder) ContentType() string { return "text/plain; charset=utf-8" }
+
+func (upperEncoder) CanEncode(v any) bool {
+	_, ok := v.(string)
+	return ok
+}
+
+func (upperEncoder) Encode(w io.Writer, v any) error {
+	_, err := io.WriteString(w, strings.ToUpper(v.(string)))
+	return err
+}
+
+func TestNegotiateUsesRegisteredEncoders(t *testing.T) {
+	builder := NewResponseBuilderService(slog.New(slog.DiscardHandler), nil)
+	builder.RegisterEncoder(upperEncoder{})
+	engine, err := NewTemplateEngine(TemplateOptions{
+		FS: fstest.MapFS{"page.html": {Data: []byte("<h1>{{.Data.Title}}</h1>")}},
+	})
+	if err != nil {
+		t.Fatalf("NewTemplateEngine() error = %v", err)
+	}
+	builder.RegisterEncoder(engine)
+
+	if body := negotiate(t, builder, "text/plain", "ok").Body.String(); body != "OK" {
+		t.Fatalf("body = %q, want the replaced text encoder", body)
+	}
+
+	recorder := negotiate(t, builder, "text/html,application/xhtml+xml", negotiatePage{Title: "<Orders>"})
+	if recorder.Body.String() != "<h1>&lt;Orders&gt;</h1>" {
+		t.Fatalf("body = %q, want the rendered template", recorder.Body.String())
+	}
+	if recorder.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Fatalf("Content-Type = %q, want text/html", recorder.Header().Get("Content-Type"))
+	}
+}
@@ -0,0 +1,294 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/golibry/go-common-domain/domain"
+	httplib "github.com/golibry/go-http/http"
+)
+
+// ProblemContentType is the media type of RFC 9457 problem details.
+const ProblemContentType = "application/problem+json"
+
+// DefaultProblemTypeBaseURI prefixes the type URIs of the default problem categories.
+const DefaultProblemTypeBaseURI = "/problems/"
+
+// Problem is an RFC 9457 problem details body.
+type Problem struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail,omitempty"`
+	Instance  string              `json:"instance,omitempty"`
+	RequestID string              `json:"request_id,omitempty"`
+	Errors    []ProblemFieldError `json:"errors,omitempty"`
+}
+
+// ProblemFieldError is an invalid field of a request, listed in Problem.Errors.
+type ProblemFieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+
+	// Rule is the failed validation tag for validator errors, e.g. "required".
+	Rule string `json:"rule,omitempty"`
+}
+
+// FieldError attributes an error, usually a *domain.Error, to a request field so that
+// problem responses list it in Problem.Errors. A *domain.Error does not name the field
+// it is about, so a bare one is only reflected in the status and the detail.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+// NewFieldError returns a FieldError for field.
+func NewFieldError(field string, err error) *FieldError {
+	return &FieldError{Field: field, Err: err}
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ProblemCategory maps the error types added with AddProblemErrorType to a status and
+// a problem type.
+type ProblemCategory struct {
+	Status int
+
+	// Type is the URI identifying the problem type, "about:blank" when empty.
+	Type string
+
+	// Title is the summary of the problem type, the status text when empty.
+	Title string
+
+	matchers   []func(err error) bool
+	errorTypes []func(category *httplib.ErrorCategory)
+}
+
+// NewProblemCategory returns a category without error types.
+func NewProblemCategory(status int, typeURI string, title string) *ProblemCategory {
+	return &ProblemCategory{Status: status, Type: typeURI, Title: title}
+}
+
+// AddProblemErrorType adds the errors matching T with errors.As to the category.
+func AddProblemErrorType[T error](category *ProblemCategory) {
+	category.matchers = append(category.matchers, func(err error) bool {
+		var target T
+		return errors.As(err, &target)
+	})
+	category.errorTypes = append(category.errorTypes, httplib.AddErrorType[T])
+}
+
+// ErrorCategory returns the go-http error category with the status and error types
+// of the category, used by ResponseBuilder.NewErrorBuilder.
+func (c *ProblemCategory) ErrorCategory() *httplib.ErrorCategory {
+	category := httplib.NewErrorCategory(c.Status)
+	for _, addErrorType := range c.errorTypes {
+		addErrorType(category)
+	}
+
+	return category
+}
+
+// ErrorCategoriesOf returns the go-http error categories of the problem categories.
+func ErrorCategoriesOf(categories []*ProblemCategory) []*httplib.ErrorCategory {
+	errorCategories := make([]*httplib.ErrorCategory, 0, len(categories))
+	for _, category := range categories {
+		errorCategories = append(errorCategories, category.ErrorCategory())
+	}
+
+	return errorCategories
+}
+
+func (c *ProblemCategory) matches(err error) bool {
+	for _, match := range c.matchers {
+		if match(err) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ProblemOptions configures the problem responses of ResponseBuilder.
+type ProblemOptions struct {
+	// Categories are matched in order against the error, see DefaultProblemCategories.
+	// Unmatched errors are internal server errors. They also classify the errors of
+	// ResponseBuilder.NewErrorBuilder. It is called once, by NewResponseBuilderService.
+	Categories func() []*ProblemCategory
+
+	// HideDetails omits the detail of server errors, which may expose internals.
+	// NewContainerFromConfig enables it in the "prod" environment.
+	HideDetails bool
+}
+
+// DefaultProblemCategories maps validator.ValidationErrors to 422 Unprocessable Entity,
+// *BindError and *domain.Error to 400 Bad Request, *BodyTooLargeError to 413 Content Too
+// Large, *UnsupportedMediaTypeError to 415 Unsupported Media Type and
+// *PreconditionFailedError to 412 Precondition Failed.
+func DefaultProblemCategories() []*ProblemCategory {
+	validation := NewProblemCategory(
+		http.StatusUnprocessableEntity,
+		DefaultProblemTypeBaseURI+"validation-error",
+		"Validation failed",
+	)
+	AddProblemErrorType[validator.ValidationErrors](validation)
+
+	domainError := NewProblemCategory(
+		http.StatusBadRequest,
+		DefaultProblemTypeBaseURI+"domain-error",
+		"Invalid request",
+	)
+	AddProblemErrorType[*domain.Error](domainError)
+
+	malformed := NewProblemCategory(
+		http.StatusBadRequest,
+		DefaultProblemTypeBaseURI+"malformed-request",
+		"Malformed request",
+	)
+	AddProblemErrorType[*BindError](malformed)
+
+	tooLarge := NewProblemCategory(
+		http.StatusRequestEntityTooLarge,
+		DefaultProblemTypeBaseURI+"body-too-large",
+		"Request body too large",
+	)
+	AddProblemErrorType[*BodyTooLargeError](tooLarge)
+
+	unsupported := NewProblemCategory(
+		http.StatusUnsupportedMediaType,
+		DefaultProblemTypeBaseURI+"unsupported-media-type",
+		"Unsupported media type",
+	)
+	AddProblemErrorType[*UnsupportedMediaTypeError](unsupported)
+
+	precondition := NewProblemCategory(
+		http.StatusPreconditionFailed,
+		DefaultProblemTypeBaseURI+"precondition-failed",
+		"Precondition failed",
+	)
+	AddProblemErrorType[*PreconditionFailedError](precondition)
+
+	return []*ProblemCategory{validation, domainError, malformed, tooLarge, unsupported, precondition}
+}
+
+// NewProblem builds the problem details of err for request.
+func (rbs *ResponseBuilder) NewProblem(request *http.Request, err error) Problem {
+	problem := Problem{
+		Type:      "about:blank",
+		Status:    http.StatusInternalServerError,
+		Instance:  request.URL.Path,
+		RequestID: RequestIDFromContext(request.Context()),
+		Errors:    problemFieldErrors(err),
+	}
+	for _, category := range rbs.problemCategories {
+		if category.matches(err) {
+			problem.Status = category.Status
+			problem.Type = category.Type
+			problem.Title = category.Title
+			break
+		}
+	}
+	if problem.Type == "" {
+		problem.Type = "about:blank"
+	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
+	}
+
+	switch {
+	case problem.Status >= http.StatusInternalServerError && rbs.problems.HideDetails:
+	case len(problem.Errors) > 0:
+		problem.Detail = fmt.Sprintf("%d request field(s) are invalid", len(problem.Errors))
+	default:
+		problem.Detail = err.Error()
+	}
+
+	return problem
+}
+
+// Problem writes the problem details of err as an application/problem+json response.
+// Server errors are logged with the request context.
+func (rbs *ResponseBuilder) Problem(w http.ResponseWriter, request *http.Request, err error) error {
+	problem := rbs.NewProblem(request, err)
+	if problem.Status >= http.StatusInternalServerError {
+		rbs.logger.ErrorContext(request.Context(), "Request failed", "status", problem.Status, "error", err)
+	}
+
+	return rbs.WriteProblem(w, problem)
+}
+
+// WriteProblem writes problem as an application/problem+json response.
+func (rbs *ResponseBuilder) WriteProblem(w http.ResponseWriter, problem Problem) error {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+	return json.NewEncoder(w).Encode(problem)
+}
+
+// problemFieldErrors collects the field errors from the validator errors, the
+// FieldErrors and the BindErrors of fields in the tree of err. Domain errors are only
+// listed when wrapped with NewFieldError.
+func problemFieldErrors(err error) []ProblemFieldError {
+	var fields []ProblemFieldError
+
+	var walk func(err error)
+	walk = func(err error) {
+		switch e := err.(type) {
+		case nil:
+			return
+		case validator.ValidationErrors:
+			for _, fieldErr := range e {
+				fields = append(fields, ProblemFieldError{
+					Field:   validationFieldName(fieldErr),
+					Message: validationMessage(fieldErr),
+					Rule:    fieldErr.Tag(),
+				})
+			}
+			return
+		case *FieldError:
+			fields = append(fields, ProblemFieldError{Field: e.Field, Message: e.Err.Error()})
+			return
+		case *BindError:
+			if e.Field != "" {
+				fields = append(fields, ProblemFieldError{Field: e.Field, Message: e.Err.Error()})
+			}
+			return
+		case interface{ Unwrap() []error }:
+			for _, wrapped := range e.Unwrap() {
+				walk(wrapped)
+			}
+		case interface{ Unwrap() error }:
+			walk(e.Unwrap())
+		}
+	}
+	walk(err)
+
+	return fields
+}
+
+// validationFieldName returns the namespace of the field without the top level struct,
+// e.g. "Address.City".
+func validationFieldName(fieldErr validator.FieldError) string {
+	if _, field, ok := strings.Cut(fieldErr.Namespace(), "."); ok {
+		return field
+	}
+
+	return fieldErr.Field()
+}
+
+func validationMessage(fieldErr validator.FieldError) string {
+	if fieldErr.Param() != "" {
+		return fmt.Sprintf("failed the %q rule with %q", fieldErr.Tag(), fieldErr.Param())
+	}
+
+	return fmt.Sprintf("failed the %q rule", fieldErr.Tag())
+}
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func TestProblemListsValidationErrors(t *testing.T) {
+	type address struct {
+		City string `validate:"required"`
+	}
+	type user struct {
+		Name    string `validate:"min=3"`
+		Address address
+	}
+	err := validator.New().Struct(user{Name: "al"})
+	if err == nil {
+		t.Fatal("Struct() error = nil, want validation errors")
+	}
+
+	builder := NewResponseBuilderService(slog.New(slog.DiscardHandler), nil)
+	request := httptest.NewRequest(http.MethodPost, "/users", nil)
+	request = request.WithContext(WithRequestID(request.Context(), "req-1"))
+	recorder := httptest.NewRecorder()
+
+	if err := builder.Problem(recorder, request, err); err != nil {
+		t.Fatalf("Problem() error = %v", err)
+	}
+
+	if recorder.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422", recorder.Code)
+	}
+	if got := recorder.Header().Get("Content-Type"); got != ProblemContentType {
+		t.Fatalf("Content-Type = %q, want %q", got, ProblemContentType)
+	}
+
+	var problem Problem
+	if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if problem.Type != "/problems/validation-error" || problem.Instance != "/users" ||
+		problem.RequestID != "req-1" {
+		t.Fatalf("problem = %+v, want the validation type, instance and request ID", problem)
+	}
+	want := []ProblemFieldError{
+		{Field: "Name", Message: `failed the "min" rule with "3"`, Rule: "min"},
+		{Field: "Address.City", Message: `failed the "required" rule`, Rule: "required"},
+	}
+	if len(problem.Errors) != len(want) || problem.Errors[0] != want[0] || problem.Errors[1] != want[1] {
+		t.Fatalf("errors = %+v, want %+v", problem.Errors, want)
+	}
+}
+
+func TestProblemListsFieldErrors(t *testing.T) {
+	conflict := NewProblemCategory(http.StatusConflict, "https://example.com/problems/conflict", "")
+	AddProblemErrorType[*FieldError](conflict)
+	calls := 0
+	builder := NewResponseBuilderService(slog.New(slog.DiscardHandler), nil, ResponseBuilderOptions{
+		Problems: ProblemOptions{
+			Categories: func() []*ProblemCategory {
+				calls++
+				return []*ProblemCategory{conflict}
+			},
+		},
+	})
+	err := errors.Join(
+		NewFieldError("email", errors.New("email is already taken")),
+		NewFieldError("age", errors.New("age must be positive")),
+	)
+
+	problem := builder.NewProblem(httptest.NewRequest(http.MethodPost, "/users", nil), err)
+
+	if problem.Status != http.StatusConflict || problem.Type != "https://example.com/problems/conflict" ||
+		problem.Title != "Conflict" {
+		t.Fatalf("problem = %+v, want the conflict category", problem)
+	}
+	if len(problem.Errors) != 2 || problem.Errors[0].Field != "email" ||
+		problem.Errors[1].Message != "age must be positive" {
+		t.Fatalf("errors = %+v, want the email and age field errors", problem.Errors)
+	}
+	if categories := builder.errorCategories(); len(categories) != 1 {
+		t.Fatalf("error categories = %d, want the conflict category only", len(categories))
+	}
+	_ = builder.NewProblem(httptest.NewRequest(http.MethodPost, "/users", nil), err)
+	if calls != 1 {
+		t.Fatalf("Categories() calls = %d, want 1", calls)
+	}
+}
+
+func TestProblemHidesServerErrorDetails(t *testing.T) {
+	output := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(output, nil))
+	request := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	err := errors.New("dial tcp 10.0.0.5:5432: connection refused")
+
+	visible := NewResponseBuilderService(logger, nil).NewProblem(request, err)
+	if visible.Detail != err.Error() {
+		t.Fatalf("detail = %q, want the error", visible.Detail)
+	}
+
+	recorder := httptest.NewRecorder()
+	hidden := NewResponseBuilderService(
+		logger,
+		nil,
+		ResponseBuilderOptions{Problems: ProblemOptions{HideDetails: true}},
+	)
+	if err := hidden.Problem(recorder, request, err); err != nil {
+		t.Fatalf("Problem() error = %v", err)
+	}
+
+	if recorder.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", recorder.Code)
+	}
+	if strings.Contains(recorder.Body.String(), "10.0.0.5") {
+		t.Fatalf("body = %q, want the detail hidden", recorder.Body.String())
+	}
+	if !strings.Contains(recorder.Body.String(), `"type":"about:blank"`) ||
+		!strings.Contains(recorder.Body.String(), `"title":"Internal Server Error"`) {
+		t.Fatalf("body = %q, want the default problem type", recorder.Body.String())
+	}
+	if !strings.Contains(output.String(), "connection refused") {
+		t.Fatalf("log = %q, want the server error logged", output.String())
+	}
+}
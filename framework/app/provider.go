@@ -0,0 +1,245 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrServiceNotRegistered = errors.New("service is not registered")
+	ErrServiceCycle         = errors.New("service dependency cycle detected")
+	ErrContainerClosed      = errors.New("container is closed")
+)
+
+// Provider lazily constructs a service of type T.
+//
+// The container passed to the provider resolves dependencies through the same
+// registrations and tracks the resolution chain used for cycle detection. It must
+// only be used while the provider runs; keep the returned services instead.
+type Provider[C any, T any] func(container *Container[C]) (T, error)
+
+// RegisterProvider registers a provider that constructs T on the first Service or
+// ResolveService call. The constructed value is cached as a singleton.
+//
+// When the constructed value implements Close() error or Close(context.Context) error,
+// it is registered as a container cleanup right after construction, so services are
+// closed in reverse construction order.
+func RegisterProvider[C any, T any](container *Container[C], provider Provider[C, T]) {
+	registerProvider(container, "", provider, ScopeSingleton, callerOrigin(2))
+}
+
+// RegisterNamedProvider registers a provider for T under a name. The empty name
+// registers the unnamed default.
+func RegisterNamedProvider[C any, T any](
+	container *Container[C],
+	name string,
+	provider Provider[C, T],
+) {
+	registerProvider(container, name, provider, ScopeSingleton, callerOrigin(2))
+}
+
+type serviceProvider[C any] struct {
+	construct func(*Container[C]) (any, error)
+	scope     string
+	origin    string
+}
+
+func registerProvider[C any, T any](
+	container *Container[C],
+	name string,
+	provider Provider[C, T],
+	scope string,
+	origin string,
+) {
+	if container == nil || container.App == nil || provider == nil {
+		return
+	}
+
+	container.mu.Lock()
+	defer container.mu.Unlock()
+
+	if container.providers == nil {
+		container.providers = make(map[serviceKey]serviceProvider[C])
+	}
+
+	key := serviceKey{typ: serviceType[T](), name: name}
+	delete(container.services, key)
+	container.providers[key] = serviceProvider[C]{
+		construct: func(container *Container[C]) (any, error) {
+			return provider(container)
+		},
+		scope:  scope,
+		origin: origin,
+	}
+	container.register(
+		key,
+		serviceRegistration{binding: bindingProvider, scope: scope, origin: origin},
+	)
+}
+
+// ResolveService returns the service registered for T, constructing it through its
+// provider on first use.
+func ResolveService[C any, T any](container *Container[C]) (T, error) {
+	return ResolveNamedService[C, T](container, "")
+}
+
+// ResolveNamedService returns the service registered for T under name, constructing it
+// through its provider on first use.
+func ResolveNamedService[C any, T any](container *Container[C], name string) (T, error) {
+	var zero T
+	key := serviceKey{typ: serviceType[T](), name: name}
+	if container == nil || container.App == nil {
+		return zero, fmt.Errorf("%w: %s", ErrServiceNotRegistered, key)
+	}
+
+	service, err := container.resolve(key)
+	if err != nil {
+		return zero, err
+	}
+
+	typed, ok := service.(T)
+	if !ok {
+		return zero, fmt.Errorf("%w: %s", ErrServiceNotRegistered, key)
+	}
+
+	return typed, nil
+}
+
+func (c *Container[C]) resolve(key serviceKey) (any, error) {
+	service, provider, owner, ok := c.find(key)
+	if ok {
+		return service, nil
+	}
+	if provider == nil {
+		return nil, fmt.Errorf("%w: %s", ErrServiceNotRegistered, key)
+	}
+
+	if provider.scope == ScopeScoped {
+		// Scoped services are constructed in the scope asking for them.
+		if c.parent == nil {
+			return nil, fmt.Errorf("%w: %s", ErrScopeRequired, key)
+		}
+	} else if owner != c {
+		// Singletons are constructed and cached by the container that registered them.
+		return owner.resolve(key)
+	}
+
+	return c.construct(key, provider)
+}
+
+func (c *Container[C]) construct(key serviceKey, provider *serviceProvider[C]) (any, error) {
+	for i, resolving := range c.resolving {
+		if resolving == key {
+			chain := append([]serviceKey(nil), c.resolving[i:]...)
+			return nil, fmt.Errorf(
+				"%w: %s",
+				ErrServiceCycle,
+				formatResolutionChain(append(chain, key)),
+			)
+		}
+	}
+
+	// Only the outermost resolution takes the lock, nested resolutions run on the
+	// container handed to the provider, which already owns it.
+	if len(c.resolving) == 0 {
+		c.resolveMu.Lock()
+		defer c.resolveMu.Unlock()
+
+		// Another goroutine may have constructed the service while we were waiting.
+		if service, _, ok := c.lookup(key); ok {
+			return service, nil
+		}
+	}
+
+	c.mu.Lock()
+	closed := c.closed
+	c.mu.Unlock()
+	if closed {
+		return nil, fmt.Errorf("%w: cannot construct service %s", ErrContainerClosed, key)
+	}
+
+	service, err := provider.construct(c.withResolving(key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct service %s: %w", key, err)
+	}
+
+	c.mu.Lock()
+	if c.services == nil {
+		c.services = make(map[serviceKey]any)
+	}
+	c.services[key] = service
+	c.mu.Unlock()
+
+	c.registerServiceCleanup(key, service, provider.origin)
+
+	return service, nil
+}
+
+// find looks the service up in the container and then in its parent scopes. It returns
+// the cached service, or the provider and the container that registered it.
+func (c *Container[C]) find(key serviceKey) (any, *serviceProvider[C], *Container[C], bool) {
+	for scope := c; scope != nil; scope = scope.parent {
+		service, provider, ok := scope.lookup(key)
+		if ok {
+			return service, nil, scope, true
+		}
+		if provider != nil {
+			return nil, provider, scope, false
+		}
+	}
+
+	return nil, nil, nil, false
+}
+
+func (c *Container[C]) lookup(key serviceKey) (any, *serviceProvider[C], bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if service, ok := c.services[key]; ok {
+		return service, nil, true
+	}
+	if provider, ok := c.providers[key]; ok {
+		return nil, &provider, false
+	}
+
+	return nil, nil, false
+}
+
+func (c *Container[C]) withResolving(key serviceKey) *Container[C] {
+	resolving := make([]serviceKey, 0, len(c.resolving)+1)
+	resolving = append(resolving, c.resolving...)
+	resolving = append(resolving, key)
+
+	scoped := *c
+	scoped.resolving = resolving
+
+	return &scoped
+}
+
+func (c *Container[C]) registerServiceCleanup(key serviceKey, service any, origin string) {
+	hook := cleanupHook{service: key.String(), origin: origin}
+
+	switch closer := service.(type) {
+	case interface{ Close(context.Context) error }:
+		hook.run = closer.Close
+	case interface{ Close() error }:
+		hook.run = func(context.Context) error {
+			return closer.Close()
+		}
+	default:
+		return
+	}
+
+	c.registerCleanup(hook)
+}
+
+func formatResolutionChain(chain []serviceKey) string {
+	names := make([]string, 0, len(chain))
+	for _, key := range chain {
+		names = append(names, key.String())
+	}
+
+	return strings.Join(names, " -> ")
+}
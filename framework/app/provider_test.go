@@ -0,0 +1,174 @@
+package app
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type testRepository struct {
+	name   string
+	closed *[]string
+}
+
+func (r *testRepository) Close() error {
+	*r.closed = append(*r.closed, r.name)
+	return nil
+}
+
+type testHandler struct {
+	repository *testRepository
+	closed     *[]string
+}
+
+func (h *testHandler) Close() error {
+	*h.closed = append(*h.closed, "handler")
+	return nil
+}
+
+type testCycleA struct{}
+
+type testCycleB struct{}
+
+func TestProviderIsResolvedLazilyAndCached(t *testing.T) {
+	container := &Container[struct{}]{App: New(struct{}{})}
+	calls := 0
+	RegisterProvider(container, func(*Container[struct{}]) (*testRepository, error) {
+		calls++
+		return &testRepository{name: "repository"}, nil
+	})
+
+	if calls != 0 {
+		t.Fatalf("provider calls before resolution = %d, want 0", calls)
+	}
+
+	first, ok := Service[struct{}, *testRepository](container)
+	if !ok {
+		t.Fatal("Service() ok = false, want true")
+	}
+	second, _ := Service[struct{}, *testRepository](container)
+
+	if first != second {
+		t.Fatal("provider service was not cached")
+	}
+	if calls != 1 {
+		t.Fatalf("provider calls = %d, want 1", calls)
+	}
+}
+
+func TestProviderResolvesDependenciesAndClosesInReverseOrder(t *testing.T) {
+	container := &Container[struct{}]{App: New(struct{}{})}
+	closed := make([]string, 0)
+
+	RegisterProvider(container, func(c *Container[struct{}]) (*testHandler, error) {
+		repository, err := ResolveService[struct{}, *testRepository](c)
+		if err != nil {
+			return nil, err
+		}
+		return &testHandler{repository: repository, closed: &closed}, nil
+	})
+	RegisterProvider(container, func(*Container[struct{}]) (*testRepository, error) {
+		return &testRepository{name: "repository", closed: &closed}, nil
+	})
+
+	handler, err := ResolveService[struct{}, *testHandler](container)
+	if err != nil {
+		t.Fatalf("ResolveService() error = %v", err)
+	}
+	if handler.repository == nil {
+		t.Fatal("handler dependency was not resolved")
+	}
+
+	if err := container.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	expected := []string{"handler", "repository"}
+	if !reflect.DeepEqual(closed, expected) {
+		t.Fatalf("cleanup order = %v, want %v", closed, expected)
+	}
+}
+
+func TestProviderDetectsDependencyCycle(t *testing.T) {
+	container := &Container[struct{}]{App: New(struct{}{})}
+	RegisterProvider(container, func(c *Container[struct{}]) (*testCycleA, error) {
+		_, err := ResolveService[struct{}, *testCycleB](c)
+		return &testCycleA{}, err
+	})
+	RegisterProvider(container, func(c *Container[struct{}]) (*testCycleB, error) {
+		_, err := ResolveService[struct{}, *testCycleA](c)
+		return &testCycleB{}, err
+	})
+
+	_, err := ResolveService[struct{}, *testCycleA](container)
+	if !errors.Is(err, ErrServiceCycle) {
+		t.Fatalf("ResolveService() error = %v, want ErrServiceCycle", err)
+	}
+
+	chain := "*app.testCycleA -> *app.testCycleB -> *app.testCycleA"
+	if !strings.Contains(err.Error(), chain) {
+		t.Fatalf("error = %q, want chain %q", err.Error(), chain)
+	}
+}
+
+func TestResolveServiceReportsMissingService(t *testing.T) {
+	container := &Container[struct{}]{App: New(struct{}{})}
+
+	_, err := ResolveService[struct{}, *testRepository](container)
+	if !errors.Is(err, ErrServiceNotRegistered) {
+		t.Fatalf("ResolveService() error = %v, want ErrServiceNotRegistered", err)
+	}
+}
+
+func TestProviderIsConstructedOnceConcurrently(t *testing.T) {
+	container := &Container[struct{}]{App: New(struct{}{})}
+	var mu sync.Mutex
+	calls := 0
+	RegisterProvider(container, func(*Container[struct{}]) (*testRepository, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		return &testRepository{}, nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := ResolveService[struct{}, *testRepository](container); err != nil {
+				t.Errorf("ResolveService() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("provider calls = %d, want 1", calls)
+	}
+}
+
+func TestNamedProviderIsResolvedIndependently(t *testing.T) {
+	container := &Container[struct{}]{App: New(struct{}{})}
+	RegisterProvider(container, func(*Container[struct{}]) (*testRepository, error) {
+		return &testRepository{name: "primary"}, nil
+	})
+	RegisterNamedProvider(container, "audit", func(*Container[struct{}]) (*testRepository, error) {
+		return &testRepository{name: "audit"}, nil
+	})
+
+	audit, err := ResolveNamedService[struct{}, *testRepository](container, "audit")
+	if err != nil {
+		t.Fatalf("ResolveNamedService() error = %v", err)
+	}
+	primary, err := ResolveService[struct{}, *testRepository](container)
+	if err != nil {
+		t.Fatalf("ResolveService() error = %v", err)
+	}
+
+	if audit.name != "audit" || primary.name != "primary" {
+		t.Fatalf("resolved names = %q, %q, want audit, primary", audit.name, primary.name)
+	}
+}
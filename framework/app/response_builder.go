@@ -2,31 +2,65 @@ package app
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"log/slog"
 	"net/http"
+	"sync"
 
-	"github.com/golibry/go-common-domain/domain"
 	httplib "github.com/golibry/go-http/http"
 )
 
 type ResponseBuilder struct {
 	logger          *slog.Logger
 	errorCategories func() []*httplib.ErrorCategory
+	problems        ProblemOptions
+
+	// problemCategories are the categories of problems, built once.
+	problemCategories []*ProblemCategory
+
+	encodersMu sync.RWMutex
+	encoders   []Encoder
+}
+
+// ResponseBuilderOptions configures a ResponseBuilder.
+type ResponseBuilderOptions struct {
+	// Problems configures the problem+json responses. Its categories also classify the
+	// errors of NewErrorBuilder unless error categories are passed explicitly.
+	Problems ProblemOptions
 }
 
+// NewResponseBuilderService returns a ResponseBuilder. The error categories of
+// NewErrorBuilder are derived from the problem categories when errorCategories is nil.
 func NewResponseBuilderService(
 	logger *slog.Logger,
 	errorCategories func() []*httplib.ErrorCategory,
+	options ...ResponseBuilderOptions,
 ) *ResponseBuilder {
-	if errorCategories == nil {
-		errorCategories = defaultErrorCategories
+	builderOptions := ResponseBuilderOptions{}
+	if len(options) > 0 {
+		builderOptions = options[0]
 	}
 
-	return &ResponseBuilder{
+	rbs := &ResponseBuilder{
 		logger:          logger,
 		errorCategories: errorCategories,
+		problems:        builderOptions.Problems,
+		encoders:        DefaultEncoders(),
 	}
+	if rbs.problems.Categories == nil {
+		rbs.problemCategories = DefaultProblemCategories()
+	} else {
+		rbs.problemCategories = rbs.problems.Categories()
+	}
+	if rbs.errorCategories == nil {
+		errorCategories := ErrorCategoriesOf(rbs.problemCategories)
+		rbs.errorCategories = func() []*httplib.ErrorCategory {
+			return errorCategories
+		}
+	}
+
+	return rbs
 }
 
 func (rbs *ResponseBuilder) NewBuilder(w http.ResponseWriter) *httplib.ResponseBuilder {
@@ -43,7 +77,25 @@ func (rbs *ResponseBuilder) NewErrorBuilder(
 		WithContext(request.Context())
 }
 
-func (rbs *ResponseBuilder) JSON(w http.ResponseWriter, status int, v any) error {
+// JSON streams v as JSON with status. Given WithCache, the response gets an ETag and
+// caching headers, and conditional requests are answered, see WithCache.
+func (rbs *ResponseBuilder) JSON(
+	w http.ResponseWriter,
+	status int,
+	v any,
+	options ...ResponseOption,
+) error {
+	var responseOptions responseOptions
+	for _, option := range options {
+		option(&responseOptions)
+	}
+	if responseOptions.cache != nil {
+		if responseOptions.request == nil {
+			return errors.New("failed to write JSON response: WithCache needs the request")
+		}
+		return rbs.cachedJSON(w, responseOptions.request, status, v, *responseOptions.cache)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	return json.NewEncoder(w).Encode(v)
@@ -79,13 +131,3 @@ func (rbs *ResponseBuilder) Redirect(
 
 	http.Redirect(w, r, url, status)
 }
-
-func defaultErrorCategories() []*httplib.ErrorCategory {
-	badRequestCategory := httplib.NewErrorCategory(http.StatusBadRequest)
-	httplib.AddErrorType[*domain.Error](badRequestCategory)
-
-	categories := make([]*httplib.ErrorCategory, 0)
-	categories = append(categories, badRequestCategory)
-
-	return categories
-}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrScopeRequired = errors.New("scoped service must be resolved from a scope")
+
+// ScopeOpener opens a child scope bound to a context. It is implemented by *Container
+// and lets non-generic code, such as HTTP middleware, manage scopes.
+type ScopeOpener interface {
+	OpenScope(ctx context.Context) (context.Context, ContextCleanup)
+}
+
+type scopeContextKey[C any] struct{}
+
+// RegisterScopedProvider registers a provider constructed once per scope, e.g. a DB
+// transaction or a per-request logger. Scoped services cannot be resolved from the root
+// container, and their cleanups run when the scope is closed.
+func RegisterScopedProvider[C any, T any](container *Container[C], provider Provider[C, T]) {
+	registerProvider(container, "", provider, ScopeScoped, callerOrigin(2))
+}
+
+// NewScope creates a child container for one request, CLI command or job.
+//
+// Services registered on the scope, and scoped services it constructs, stay in the
+// scope. Singletons are resolved through the parent. Close the scope to run its own
+// cleanups; the parent is left untouched.
+func (c *Container[C]) NewScope() *Container[C] {
+	return &Container[C]{
+		App:             New(c.Config()),
+		loggerService:   c.loggerService,
+		dbService:       c.dbService,
+		responseBuilder: c.responseBuilder,
+		parent:          c,
+	}
+}
+
+// OpenScope creates a scope, stores it in the returned context and returns the cleanup
+// that closes it.
+func (c *Container[C]) OpenScope(ctx context.Context) (context.Context, ContextCleanup) {
+	scope := c.NewScope()
+	return WithScope(ctx, scope), scope.CloseContext
+}
+
+// Parent returns the container the scope was created from, nil for the root container.
+func (c *Container[C]) Parent() *Container[C] {
+	return c.parent
+}
+
+func (c *Container[C]) scope() string {
+	if c.parent == nil {
+		return ScopeSingleton
+	}
+
+	return ScopeScoped
+}
+
+// WithScope returns a copy of ctx carrying the scope.
+func WithScope[C any](ctx context.Context, scope *Container[C]) context.Context {
+	return context.WithValue(ctx, scopeContextKey[C]{}, scope)
+}
+
+// ScopeFromContext returns the scope stored in ctx by WithScope or OpenScope.
+func ScopeFromContext[C any](ctx context.Context) (*Container[C], bool) {
+	if ctx == nil {
+		return nil, false
+	}
+
+	scope, ok := ctx.Value(scopeContextKey[C]{}).(*Container[C])
+	return scope, ok && scope != nil
+}
@@ -0,0 +1,101 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testTransaction struct {
+	id     int
+	closed *[]string
+}
+
+func (t *testTransaction) Close() error {
+	*t.closed = append(*t.closed, "transaction")
+	return nil
+}
+
+func TestScopeConstructsScopedServicesPerScope(t *testing.T) {
+	root := &Container[struct{}]{App: New(struct{}{})}
+	closed := make([]string, 0)
+	singletonCalls, scopedCalls := 0, 0
+
+	RegisterProvider(root, func(*Container[struct{}]) (*testRepository, error) {
+		singletonCalls++
+		return &testRepository{name: "repository", closed: &closed}, nil
+	})
+	RegisterScopedProvider(root, func(c *Container[struct{}]) (*testTransaction, error) {
+		if _, err := ResolveService[struct{}, *testRepository](c); err != nil {
+			return nil, err
+		}
+		scopedCalls++
+		return &testTransaction{id: scopedCalls, closed: &closed}, nil
+	})
+
+	first := root.NewScope()
+	second := root.NewScope()
+
+	firstTx, err := ResolveService[struct{}, *testTransaction](first)
+	if err != nil {
+		t.Fatalf("ResolveService() error = %v", err)
+	}
+	sameTx, _ := ResolveService[struct{}, *testTransaction](first)
+	secondTx, _ := ResolveService[struct{}, *testTransaction](second)
+
+	if firstTx != sameTx {
+		t.Fatal("scoped service was not cached within the scope")
+	}
+	if firstTx == secondTx {
+		t.Fatal("scoped service was shared between scopes")
+	}
+	if singletonCalls != 1 || scopedCalls != 2 {
+		t.Fatalf("calls = %d singleton, %d scoped, want 1, 2", singletonCalls, scopedCalls)
+	}
+
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if !reflect.DeepEqual(closed, []string{"transaction"}) {
+		t.Fatalf("closed after scope = %v, want [transaction]", closed)
+	}
+
+	if _, ok := Service[struct{}, *testRepository](root); !ok {
+		t.Fatal("singleton was not kept in the root container")
+	}
+}
+
+func TestScopedServiceRequiresScope(t *testing.T) {
+	root := &Container[struct{}]{App: New(struct{}{})}
+	RegisterScopedProvider(root, func(*Container[struct{}]) (*testTransaction, error) {
+		return &testTransaction{}, nil
+	})
+
+	if _, err := ResolveService[struct{}, *testTransaction](root); !errors.Is(err, ErrScopeRequired) {
+		t.Fatalf("ResolveService() error = %v, want ErrScopeRequired", err)
+	}
+}
+
+func TestScopeKeepsValuesAndFallsBackToParent(t *testing.T) {
+	root := &Container[struct{}]{App: New(struct{}{})}
+	repository := &testRepository{name: "repository"}
+	RegisterService(root, repository)
+
+	ctx, closeScope := root.OpenScope(context.Background())
+	scope, ok := ScopeFromContext[struct{}](ctx)
+	if !ok {
+		t.Fatal("ScopeFromContext() ok = false, want true")
+	}
+	RegisterService(scope, &testTransaction{id: 7})
+
+	if service, _ := Service[struct{}, *testRepository](scope); service != repository {
+		t.Fatal("scope did not fall back to the parent service")
+	}
+	if _, ok := Service[struct{}, *testTransaction](root); ok {
+		t.Fatal("scope value leaked into the parent")
+	}
+	if err := closeScope(ctx); err != nil {
+		t.Fatalf("closeScope() error = %v", err)
+	}
+}
@@ -3,40 +3,139 @@ package app
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
+	"sync"
+	"time"
 
 	"github.com/golibry/go-web-skeleton/framework/config"
 )
 
 type SQLDBOptions struct {
 	PingOnStartup bool
+
+	// ConnectRetry configures the startup ping retries, see PingWithRetry.
+	// The zero value pings once.
+	ConnectRetry ConnectRetryPolicy
+
+	// Logger receives the startup ping attempts, slog.Default when nil.
+	Logger *slog.Logger
+
+	// TxRetry is the retry policy of InTx calls without their own policy,
+	// DefaultTxRetryPolicy when nil.
+	TxRetry *TxRetryPolicy
+
+	// QueryObservers are notified of the operations run on the primary and the replicas,
+	// see QueryObserver. The driver is not wrapped when there are no observers and
+	// tracers.
+	QueryObservers []QueryObserver
+
+	// QueryTracers are called before the operations run on the primary and the
+	// replicas, e.g. to start tracing spans, see QueryTracer.
+	QueryTracers []QueryTracer
+
+	// QueryArgs passes the query arguments, with sensitive values masked, to the
+	// tracers and observers. They are omitted by default.
+	QueryArgs bool
+
+	// Name identifies the database in pool statistics and health checks, "main" when
+	// empty.
+	Name string
+
+	// StatsInterval is how often the pool statistics are reported to StatsObservers.
+	// Sampling is disabled when it is zero or there are no observers.
+	StatsInterval  time.Duration
+	StatsObservers []PoolStatsObserver
+
+	// HealthCheckTimeout bounds the ping of CheckHealth, 2 seconds when zero.
+	HealthCheckTimeout time.Duration
 }
 
 type SQLDBService struct {
-	db *sql.DB
+	db            *sql.DB
+	name          string
+	driver        string
+	replicas      *replicaSet
+	txRetry       TxRetryPolicy
+	healthTimeout time.Duration
+	stopStats     chan struct{}
+	statsWG       sync.WaitGroup
 }
 
 type SqlDbService = SQLDBService
 
+// NewDBService opens the primary connection pool and one pool per configured read
+// replica. Replicas are health checked in the background when the configuration
+// enables it.
 func NewDBService(dbConfig config.Database, options ...SQLDBOptions) (*SQLDBService, error) {
-	db, err := createDbConnectionPool(dbConfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create sql db service: %w", err)
-	}
-
 	dbOptions := SQLDBOptions{}
 	if len(options) > 0 {
 		dbOptions = options[0]
 	}
+
+	var instrumentation *sqlInstrumentation
+	if len(dbOptions.QueryObservers) > 0 || len(dbOptions.QueryTracers) > 0 {
+		instrumentation = &sqlInstrumentation{
+			tracers:   dbOptions.QueryTracers,
+			observers: dbOptions.QueryObservers,
+			args:      dbOptions.QueryArgs,
+		}
+	}
+
+	db, err := createDbConnectionPool(dbConfig, dbConfig.Dsn, instrumentation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sql db service: %w", err)
+	}
 	if dbOptions.PingOnStartup {
-		if err := db.PingContext(context.Background()); err != nil {
+		if err := PingWithRetry(BaseContext(), db, dbOptions.ConnectRetry, dbOptions.Logger); err != nil {
 			_ = db.Close()
 			return nil, fmt.Errorf("failed to ping sql db: %w", err)
 		}
 	}
 
 	service := &SQLDBService{
-		db: db,
+		db:            db,
+		name:          dbOptions.Name,
+		driver:        dbConfig.Driver,
+		txRetry:       DefaultTxRetryPolicy(),
+		healthTimeout: dbOptions.HealthCheckTimeout,
+	}
+	if service.name == "" {
+		service.name = "main"
+	}
+	if service.healthTimeout <= 0 {
+		service.healthTimeout = defaultHealthCheckTimeout
+	}
+	if dbOptions.TxRetry != nil {
+		service.txRetry = *dbOptions.TxRetry
+	}
+
+	if len(dbConfig.ReplicaDsns) > 0 {
+		replicas := make([]*sql.DB, 0, len(dbConfig.ReplicaDsns))
+		for i, dsn := range dbConfig.ReplicaDsns {
+			replica, err := createDbConnectionPool(dbConfig, dsn, instrumentation)
+			if err != nil {
+				for _, opened := range replicas {
+					_ = opened.Close()
+				}
+				_ = db.Close()
+				return nil, fmt.Errorf("failed to create sql db replica %d: %w", i, err)
+			}
+			replicas = append(replicas, replica)
+		}
+
+		service.replicas = newReplicaSet(replicas, replicaSetOptions{
+			selection:           dbConfig.ReplicaSelection,
+			healthCheckInterval: dbConfig.ReplicaHealthCheckInterval,
+			maxFailures:         dbConfig.ReplicaMaxFailures,
+		})
+	}
+
+	if dbOptions.StatsInterval > 0 && len(dbOptions.StatsObservers) > 0 {
+		service.stopStats = make(chan struct{})
+		service.statsWG.Add(1)
+		go service.sampleStats(dbOptions.StatsInterval, dbOptions.StatsObservers)
 	}
 
 	return service, nil
@@ -46,6 +145,7 @@ func NewDbService(dbConfig config.Database) (*SQLDBService, error) {
 	return NewDBService(dbConfig)
 }
 
+// DB returns the primary connection pool.
 func (d *SQLDBService) DB() *sql.DB {
 	return d.db
 }
@@ -54,16 +154,76 @@ func (d *SQLDBService) Db() *sql.DB {
 	return d.DB()
 }
 
+// Writer returns the primary connection pool.
+func (d *SQLDBService) Writer() *sql.DB {
+	return d.db
+}
+
+// Reader returns a healthy read replica picked by the configured selection. It returns
+// the primary when there are no healthy replicas or when ctx was marked with
+// WithPrimary, e.g. to read data written earlier in the same request.
+func (d *SQLDBService) Reader(ctx context.Context) *sql.DB {
+	if d.replicas == nil || PrimaryForced(ctx) {
+		return d.db
+	}
+
+	if replica := d.replicas.pick(); replica != nil {
+		return replica
+	}
+
+	return d.db
+}
+
 func (d *SQLDBService) Close() error {
+	if d.stopStats != nil {
+		close(d.stopStats)
+		d.statsWG.Wait()
+		d.stopStats = nil
+	}
+
+	errs := make([]error, 0)
+	if d.replicas != nil {
+		errs = append(errs, d.replicas.close())
+	}
 	if d.db != nil {
-		return d.db.Close()
+		errs = append(errs, d.db.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+type primaryContextKey struct{}
+
+// WithPrimary returns a copy of ctx that makes SQLDBService.Reader return the primary,
+// for read-your-writes consistency.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryContextKey{}, true)
+}
+
+// PrimaryForced reports whether ctx was marked with WithPrimary.
+func PrimaryForced(ctx context.Context) bool {
+	if ctx == nil {
+		return false
 	}
-	return nil
+	forced, _ := ctx.Value(primaryContextKey{}).(bool)
+
+	return forced
 }
 
-// createDbConnectionPool creates and configures a database connection
-func createDbConnectionPool(config config.Database) (*sql.DB, error) {
-	db, err := sql.Open(config.Driver, config.Dsn)
+// createDbConnectionPool creates and configures a database connection, instrumented
+// when instrumentation is not nil
+func createDbConnectionPool(
+	config config.Database,
+	dsn string,
+	instrumentation *sqlInstrumentation,
+) (*sql.DB, error) {
+	var db *sql.DB
+	var err error
+	if instrumentation != nil {
+		db, err = openInstrumentedDB(config.Driver, dsn, instrumentation)
+	} else {
+		db, err = sql.Open(config.Driver, dsn)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to sql db connection pool: %w", err)
 	}
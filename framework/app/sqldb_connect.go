@@ -0,0 +1,108 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/golibry/go-web-skeleton/framework/config"
+)
+
+// ConnectRetryPolicy configures how PingWithRetry waits for a database to become
+// reachable on startup.
+type ConnectRetryPolicy struct {
+	// Timeout is the total time allowed for all attempts. A value of 0 pings once.
+	Timeout time.Duration
+
+	// InitialBackoff is the wait after the first failed attempt, doubled after every
+	// further failure up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// Jitter randomizes every wait by up to this fraction of it, between 0 and 1.
+	Jitter float64
+}
+
+// ConnectRetryPolicyFromConfig returns the retry policy configured for a database.
+func ConnectRetryPolicyFromConfig(database config.Database) ConnectRetryPolicy {
+	return ConnectRetryPolicy{
+		Timeout:        database.ConnectTimeout,
+		InitialBackoff: database.ConnectRetryBackoff,
+		MaxBackoff:     database.ConnectRetryMaxBackoff,
+		Jitter:         0.2,
+	}
+}
+
+// PingWithRetry pings db until it answers, retrying with exponential backoff and
+// jitter until the policy timeout or ctx is done. Every failed attempt is logged.
+func PingWithRetry(
+	ctx context.Context,
+	db *sql.DB,
+	policy ConnectRetryPolicy,
+	logger *slog.Logger,
+) error {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if policy.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.Timeout)
+		defer cancel()
+	}
+
+	backoff := policy.InitialBackoff
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			if attempt > 1 {
+				logger.InfoContext(ctx, "Database is reachable", "attempt", attempt)
+			}
+			return nil
+		}
+		if policy.Timeout <= 0 {
+			return err
+		}
+
+		wait := jitter(backoff, policy.Jitter)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return fmt.Errorf("database not reachable after %d attempts: %w", attempt, err)
+		}
+		logger.WarnContext(
+			ctx,
+			"Database not reachable, retrying",
+			"attempt", attempt,
+			"retry_in", wait,
+			"error", err,
+		)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("database not reachable after %d attempts: %w", attempt, err)
+		case <-timer.C:
+		}
+
+		backoff *= 2
+		if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
+
+func jitter(wait time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return wait
+	}
+	fraction = min(fraction, 1)
+
+	delta := float64(wait) * fraction * (2*rand.Float64() - 1)
+
+	return wait + time.Duration(delta)
+}
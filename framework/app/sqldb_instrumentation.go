@@ -0,0 +1,541 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// Operations reported by QueryEvent.
+const (
+	QueryOperationQuery    = "query"
+	QueryOperationExec     = "exec"
+	QueryOperationBegin    = "begin"
+	QueryOperationCommit   = "commit"
+	QueryOperationRollback = "rollback"
+)
+
+// QueryEvent describes a finished database operation.
+type QueryEvent struct {
+	Operation string
+	Query     string
+
+	// Args are the query arguments with sensitive values masked. They are nil unless
+	// SQLDBOptions.QueryArgs is set.
+	Args []any
+
+	Start    time.Time
+	Duration time.Duration
+
+	// RowsAffected is reported for exec operations, -1 otherwise.
+	RowsAffected int64
+
+	Err error
+}
+
+// QueryObserver is notified after every query, exec and transaction operation run
+// through an instrumented connection pool.
+type QueryObserver interface {
+	ObserveQuery(ctx context.Context, event QueryEvent)
+}
+
+// QueryObserverFunc adapts a function to a QueryObserver.
+type QueryObserverFunc func(ctx context.Context, event QueryEvent)
+
+func (f QueryObserverFunc) ObserveQuery(ctx context.Context, event QueryEvent) {
+	f(ctx, event)
+}
+
+// QueryStart describes a database operation about to run.
+type QueryStart struct {
+	Operation string
+	Query     string
+
+	// Args are masked like QueryEvent.Args, nil unless SQLDBOptions.QueryArgs is set.
+	Args []any
+
+	Start time.Time
+}
+
+// QueryTracer is called before every operation run through an instrumented connection
+// pool, e.g. to start a span. The returned context is passed to the driver and the
+// observers, and finish is called with the result of the operation. Operations the
+// driver skips to run them as prepared statements finish with driver.ErrSkip, and the
+// prepared statement run is traced separately.
+type QueryTracer interface {
+	StartQuery(ctx context.Context, start QueryStart) (context.Context, func(event QueryEvent))
+}
+
+// QueryTracerFunc adapts a function to a QueryTracer.
+type QueryTracerFunc func(ctx context.Context, start QueryStart) (context.Context, func(event QueryEvent))
+
+func (f QueryTracerFunc) StartQuery(ctx context.Context, start QueryStart) (context.Context, func(event QueryEvent)) {
+	return f(ctx, start)
+}
+
+// MetricsRecorder is the part of a metrics registry used by the query metrics observer.
+type MetricsRecorder interface {
+	IncCounter(name string, labels map[string]string)
+	ObserveHistogram(name string, value float64, labels map[string]string)
+}
+
+// NewSlowQueryLogger logs operations taking at least threshold as warnings.
+func NewSlowQueryLogger(logger *slog.Logger, threshold time.Duration) QueryObserver {
+	return QueryObserverFunc(func(ctx context.Context, event QueryEvent) {
+		if event.Duration < threshold {
+			return
+		}
+
+		attrs := []any{
+			"operation", event.Operation,
+			"query", event.Query,
+			"duration", event.Duration,
+		}
+		if event.RowsAffected >= 0 {
+			attrs = append(attrs, "rows_affected", event.RowsAffected)
+		}
+		if event.Args != nil {
+			attrs = append(attrs, "args", event.Args)
+		}
+		if event.Err != nil {
+			attrs = append(attrs, "error", event.Err)
+		}
+
+		logger.WarnContext(ctx, "Slow SQL operation", attrs...)
+	})
+}
+
+// NewQueryMetricsObserver records the "sql_operations_total" and
+// "sql_operation_errors_total" counters and the "sql_operation_duration_seconds"
+// histogram, labeled by operation.
+func NewQueryMetricsObserver(recorder MetricsRecorder) QueryObserver {
+	return QueryObserverFunc(func(_ context.Context, event QueryEvent) {
+		labels := map[string]string{"operation": event.Operation}
+		recorder.IncCounter("sql_operations_total", labels)
+		recorder.ObserveHistogram("sql_operation_duration_seconds", event.Duration.Seconds(), labels)
+		if event.Err != nil {
+			recorder.IncCounter("sql_operation_errors_total", labels)
+		}
+	})
+}
+
+// sqlInstrumentation notifies the tracers and observers of an instrumented connection
+// pool.
+type sqlInstrumentation struct {
+	tracers   []QueryTracer
+	observers []QueryObserver
+	args      bool
+}
+
+// openInstrumentedDB opens a connection pool whose driver connections notify the
+// observers.
+func openInstrumentedDB(
+	driverName string,
+	dsn string,
+	instrumentation *sqlInstrumentation,
+) (*sql.DB, error) {
+	// sql.Open does not connect, it only looks up the registered driver
+	lookup, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, err
+	}
+	baseDriver := lookup.Driver()
+	_ = lookup.Close()
+
+	var connector driver.Connector = &dsnConnector{dsn: dsn, driver: baseDriver}
+	if driverContext, ok := baseDriver.(driver.DriverContext); ok {
+		connector, err = driverContext.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sql.OpenDB(&instrumentedConnector{base: connector, instrumentation: instrumentation}), nil
+}
+
+func (i *sqlInstrumentation) observe(
+	ctx context.Context,
+	operation string,
+	query string,
+	args []driver.NamedValue,
+	run func(ctx context.Context) (int64, error),
+) error {
+	var redactedArgs []any
+	if i.args && len(args) > 0 {
+		redactedArgs = redactQueryArgs(args)
+	}
+
+	start := time.Now()
+	finishers := make([]func(event QueryEvent), 0, len(i.tracers))
+	for _, tracer := range i.tracers {
+		var finish func(event QueryEvent)
+		ctx, finish = tracer.StartQuery(ctx, QueryStart{
+			Operation: operation,
+			Query:     query,
+			Args:      redactedArgs,
+			Start:     start,
+		})
+		if finish != nil {
+			finishers = append(finishers, finish)
+		}
+	}
+
+	rowsAffected, err := run(ctx)
+	event := QueryEvent{
+		Operation:    operation,
+		Query:        query,
+		Args:         redactedArgs,
+		Start:        start,
+		Duration:     time.Since(start),
+		RowsAffected: rowsAffected,
+		Err:          err,
+	}
+	// Spans end in the reverse order they were started
+	for j := len(finishers) - 1; j >= 0; j-- {
+		finishers[j](event)
+	}
+	if errors.Is(err, driver.ErrSkip) {
+		// database/sql retries through a prepared statement, which is observed instead
+		return err
+	}
+
+	for _, observer := range i.observers {
+		observer.ObserveQuery(ctx, event)
+	}
+
+	return err
+}
+
+func redactQueryArgs(args []driver.NamedValue) []any {
+	redacted := make([]any, 0, len(args))
+	for _, arg := range args {
+		switch value := arg.Value.(type) {
+		case string:
+			for _, detector := range DefaultValueDetectors {
+				value, _ = detector(value)
+			}
+			redacted = append(redacted, value)
+		case []byte:
+			redacted = append(redacted, fmt.Sprintf("[%d bytes]", len(value)))
+		default:
+			redacted = append(redacted, value)
+		}
+	}
+
+	return redacted
+}
+
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c *dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type instrumentedConnector struct {
+	base            driver.Connector
+	instrumentation *sqlInstrumentation
+}
+
+func (c *instrumentedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &instrumentedConn{base: conn, instrumentation: c.instrumentation}, nil
+}
+
+func (c *instrumentedConnector) Driver() driver.Driver {
+	return c.base.Driver()
+}
+
+// Close closes the base connector when it needs closing, sql.DB.Close calls it.
+func (c *instrumentedConnector) Close() error {
+	if closer, ok := c.base.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// instrumentedConn forwards to the driver connection, observing queries, execs and
+// transactions. Optional driver interfaces missing on the base connection fall back
+// to the database/sql defaults.
+type instrumentedConn struct {
+	base            driver.Conn
+	instrumentation *sqlInstrumentation
+}
+
+func (c *instrumentedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.base.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.base.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	instrumented := &instrumentedStmt{
+		base:            stmt,
+		conn:            c.base,
+		query:           query,
+		instrumentation: c.instrumentation,
+	}
+	if converter, ok := stmt.(driver.ColumnConverter); ok {
+		return &columnConverterStmt{instrumentedStmt: instrumented, converter: converter}, nil
+	}
+
+	return instrumented, nil
+}
+
+func (c *instrumentedConn) Close() error {
+	return c.base.Close()
+}
+
+func (c *instrumentedConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var tx driver.Tx
+	err := c.instrumentation.observe(ctx, QueryOperationBegin, "", nil, func(ctx context.Context) (int64, error) {
+		var err error
+		if beginner, ok := c.base.(driver.ConnBeginTx); ok {
+			tx, err = beginner.BeginTx(ctx, opts)
+			return -1, err
+		}
+
+		// The errors of database/sql for drivers without BeginTx
+		if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+			return -1, errors.New("sql: driver does not support non-default isolation level")
+		}
+		if opts.ReadOnly {
+			return -1, errors.New("sql: driver does not support read-only transactions")
+		}
+		tx, err = c.base.Begin()
+		return -1, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &instrumentedTx{base: tx, ctx: ctx, instrumentation: c.instrumentation}, nil
+}
+
+func (c *instrumentedConn) ExecContext(
+	ctx context.Context,
+	query string,
+	args []driver.NamedValue,
+) (driver.Result, error) {
+	execer, ok := c.base.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	var result driver.Result
+	err := c.instrumentation.observe(ctx, QueryOperationExec, query, args, func(ctx context.Context) (int64, error) {
+		var err error
+		result, err = execer.ExecContext(ctx, query, args)
+		return rowsAffected(result, err), err
+	})
+
+	return result, err
+}
+
+func (c *instrumentedConn) QueryContext(
+	ctx context.Context,
+	query string,
+	args []driver.NamedValue,
+) (driver.Rows, error) {
+	queryer, ok := c.base.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	var rows driver.Rows
+	err := c.instrumentation.observe(ctx, QueryOperationQuery, query, args, func(ctx context.Context) (int64, error) {
+		var err error
+		rows, err = queryer.QueryContext(ctx, query, args)
+		return -1, err
+	})
+
+	return rows, err
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.base.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+
+	return nil
+}
+
+func (c *instrumentedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.base.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+func (c *instrumentedConn) IsValid() bool {
+	if validator, ok := c.base.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+
+	return true
+}
+
+func (c *instrumentedConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.base.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+
+	return driver.ErrSkip
+}
+
+type instrumentedStmt struct {
+	base            driver.Stmt
+	conn            driver.Conn
+	query           string
+	instrumentation *sqlInstrumentation
+}
+
+// columnConverterStmt is an instrumentedStmt of a statement converting its arguments
+// per column. database/sql falls back to the default conversion for statements
+// without driver.ColumnConverter, so instrumentedStmt must not implement it.
+type columnConverterStmt struct {
+	*instrumentedStmt
+	converter driver.ColumnConverter
+}
+
+func (s *columnConverterStmt) ColumnConverter(index int) driver.ValueConverter {
+	return s.converter.ColumnConverter(index)
+}
+
+func (s *instrumentedStmt) Close() error {
+	return s.base.Close()
+}
+
+func (s *instrumentedStmt) NumInput() int {
+	return s.base.NumInput()
+}
+
+func (s *instrumentedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *instrumentedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *instrumentedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	var result driver.Result
+	err := s.instrumentation.observe(ctx, QueryOperationExec, s.query, args, func(ctx context.Context) (int64, error) {
+		var err error
+		if execer, ok := s.base.(driver.StmtExecContext); ok {
+			result, err = execer.ExecContext(ctx, args)
+		} else {
+			result, err = s.base.Exec(values(args))
+		}
+		return rowsAffected(result, err), err
+	})
+
+	return result, err
+}
+
+func (s *instrumentedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	var rows driver.Rows
+	err := s.instrumentation.observe(ctx, QueryOperationQuery, s.query, args, func(ctx context.Context) (int64, error) {
+		var err error
+		if queryer, ok := s.base.(driver.StmtQueryContext); ok {
+			rows, err = queryer.QueryContext(ctx, args)
+		} else {
+			rows, err = s.base.Query(values(args))
+		}
+		return -1, err
+	})
+
+	return rows, err
+}
+
+// CheckNamedValue uses the checker of the statement, or else of the connection, like
+// database/sql does for the statements of drivers.
+func (s *instrumentedStmt) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := s.base.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	if checker, ok := s.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+
+	return driver.ErrSkip
+}
+
+type instrumentedTx struct {
+	base            driver.Tx
+	ctx             context.Context
+	instrumentation *sqlInstrumentation
+}
+
+func (t *instrumentedTx) Commit() error {
+	return t.instrumentation.observe(t.ctx, QueryOperationCommit, "", nil, func(context.Context) (int64, error) {
+		return -1, t.base.Commit()
+	})
+}
+
+func (t *instrumentedTx) Rollback() error {
+	return t.instrumentation.observe(t.ctx, QueryOperationRollback, "", nil, func(context.Context) (int64, error) {
+		return -1, t.base.Rollback()
+	})
+}
+
+func rowsAffected(result driver.Result, err error) int64 {
+	if err != nil || result == nil {
+		return -1
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return -1
+	}
+
+	return rows
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, 0, len(args))
+	for i, arg := range args {
+		named = append(named, driver.NamedValue{Ordinal: i + 1, Value: arg})
+	}
+
+	return named
+}
+
+func values(args []driver.NamedValue) []driver.Value {
+	converted := make([]driver.Value, 0, len(args))
+	for _, arg := range args {
+		converted = append(converted, arg.Value)
+	}
+
+	return converted
+}
@@ -0,0 +1,244 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golibry/go-web-skeleton/framework/config"
+)
+
+func TestInstrumentedDBNotifiesObservers(t *testing.T) {
+	events := make([]QueryEvent, 0)
+	observer := QueryObserverFunc(func(_ context.Context, event QueryEvent) {
+		events = append(events, event)
+	})
+
+	service, err := NewDBService(
+		config.Database{Driver: "apptest", Dsn: "instrumented"},
+		SQLDBOptions{QueryObservers: []QueryObserver{observer}},
+	)
+	if err != nil {
+		t.Fatalf("NewDBService() error = %v", err)
+	}
+	defer func() { _ = service.Close() }()
+	testSQLDriver.reset()
+
+	tx, err := service.Writer().Begin()
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	if _, err := tx.Exec("UPDATE users SET password = ?", "hunter2"); err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	operations := make([]string, 0, len(events))
+	for _, event := range events {
+		operations = append(operations, event.Operation)
+	}
+	expected := []string{QueryOperationBegin, QueryOperationExec, QueryOperationCommit}
+	if !reflect.DeepEqual(operations, expected) {
+		t.Fatalf("operations = %v, want %v", operations, expected)
+	}
+	exec := events[1]
+	if exec.Query != "UPDATE users SET password = ?" || exec.RowsAffected != 0 || exec.Args != nil {
+		t.Fatalf("exec event = %+v, want the query, 0 rows and no arguments", exec)
+	}
+}
+
+func TestSlowQueryLoggerLogsOnlySlowOperations(t *testing.T) {
+	output := &bytes.Buffer{}
+	observer := NewSlowQueryLogger(slog.New(slog.NewTextHandler(output, nil)), 100*time.Millisecond)
+
+	observer.ObserveQuery(context.Background(), QueryEvent{
+		Operation: QueryOperationQuery, Query: "SELECT 1", Duration: time.Millisecond, RowsAffected: -1,
+	})
+	observer.ObserveQuery(context.Background(), QueryEvent{
+		Operation: QueryOperationQuery, Query: "SELECT 2", Duration: time.Second, RowsAffected: -1,
+	})
+
+	if strings.Contains(output.String(), "SELECT 1") ||
+		!strings.Contains(output.String(), `msg="Slow SQL operation" operation=query query="SELECT 2" duration=1s`) {
+		t.Fatalf("log = %q, want only the slow query", output.String())
+	}
+}
+
+type testSpanKey struct{}
+
+func TestInstrumentedDBStartsAndFinishesTraces(t *testing.T) {
+	started := make([]QueryStart, 0)
+	finished := make([]QueryEvent, 0)
+	tracer := QueryTracerFunc(func(ctx context.Context, start QueryStart) (context.Context, func(QueryEvent)) {
+		started = append(started, start)
+		return context.WithValue(ctx, testSpanKey{}, start.Operation), func(event QueryEvent) {
+			finished = append(finished, event)
+		}
+	})
+	observedSpans := make([]any, 0)
+	observer := QueryObserverFunc(func(ctx context.Context, _ QueryEvent) {
+		observedSpans = append(observedSpans, ctx.Value(testSpanKey{}))
+	})
+
+	service, err := NewDBService(
+		config.Database{Driver: "apptest", Dsn: "traced"},
+		SQLDBOptions{
+			QueryTracers:   []QueryTracer{tracer},
+			QueryObservers: []QueryObserver{observer},
+			QueryArgs:      true,
+		},
+	)
+	if err != nil {
+		t.Fatalf("NewDBService() error = %v", err)
+	}
+	defer func() { _ = service.Close() }()
+
+	if _, err := service.Writer().Exec("UPDATE users SET token = ?", "Bearer abc123"); err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+
+	if len(started) != 1 || started[0].Operation != QueryOperationExec ||
+		!reflect.DeepEqual(started[0].Args, []any{"Bearer [REDACTED]"}) {
+		t.Fatalf("started = %+v, want the exec with masked arguments", started)
+	}
+	if len(finished) != 1 || finished[0].Query != "UPDATE users SET token = ?" || finished[0].Err != nil {
+		t.Fatalf("finished = %+v, want the exec result", finished)
+	}
+	if !reflect.DeepEqual(observedSpans, []any{QueryOperationExec}) {
+		t.Fatalf("observed spans = %v, want the observer called with the traced context", observedSpans)
+	}
+}
+
+// basicDriver is a driver with a closable connector, connections without BeginTx and
+// statements converting their arguments per column.
+type basicDriver struct {
+	closed    bool
+	converted []any
+}
+
+func (d *basicDriver) Open(string) (driver.Conn, error) {
+	return basicConn{driver: d}, nil
+}
+
+func (d *basicDriver) OpenConnector(string) (driver.Connector, error) {
+	return basicConnector{driver: d}, nil
+}
+
+type basicConnector struct {
+	driver *basicDriver
+}
+
+func (c basicConnector) Connect(context.Context) (driver.Conn, error) {
+	return basicConn(c), nil
+}
+
+func (c basicConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+func (c basicConnector) Close() error {
+	c.driver.closed = true
+	return nil
+}
+
+type basicConn struct {
+	driver *basicDriver
+}
+
+func (c basicConn) Prepare(string) (driver.Stmt, error) {
+	return basicStmt(c), nil
+}
+
+func (c basicConn) Close() error {
+	return nil
+}
+
+func (c basicConn) Begin() (driver.Tx, error) {
+	return basicTx{}, nil
+}
+
+type basicStmt struct {
+	driver *basicDriver
+}
+
+func (s basicStmt) Close() error {
+	return nil
+}
+
+func (s basicStmt) NumInput() int {
+	return -1
+}
+
+func (s basicStmt) Exec([]driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+func (s basicStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s basicStmt) ColumnConverter(int) driver.ValueConverter {
+	return driver.ValueConverter(basicConverter(s))
+}
+
+type basicConverter struct {
+	driver *basicDriver
+}
+
+func (c basicConverter) ConvertValue(v any) (driver.Value, error) {
+	c.driver.converted = append(c.driver.converted, v)
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
+type basicTx struct{}
+
+func (basicTx) Commit() error {
+	return nil
+}
+
+func (basicTx) Rollback() error {
+	return nil
+}
+
+func TestInstrumentedDBPassesOptionalDriverInterfacesThrough(t *testing.T) {
+	basic := &basicDriver{}
+	sql.Register("appbasic", basic)
+	db, err := openInstrumentedDB("appbasic", "basic", &sqlInstrumentation{})
+	if err != nil {
+		t.Fatalf("openInstrumentedDB() error = %v", err)
+	}
+
+	if _, err := db.Exec("UPDATE users SET name = ?", "ada"); err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	if !reflect.DeepEqual(basic.converted, []any{"ada"}) {
+		t.Fatalf("converted = %v, want the argument converted by the column converter", basic.converted)
+	}
+
+	for _, opts := range []*sql.TxOptions{{Isolation: sql.LevelSerializable}, {ReadOnly: true}} {
+		if _, err := db.BeginTx(context.Background(), opts); err == nil {
+			t.Fatalf("BeginTx(%+v) error = nil, want the unsupported option reported", opts)
+		}
+	}
+	tx, err := db.BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("BeginTx() error = %v", err)
+	}
+	_ = tx.Rollback()
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if !basic.closed {
+		t.Fatal("connector not closed, want sql.DB.Close to close it")
+	}
+}
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golibry/go-web-skeleton/framework/config"
+)
+
+type replicaSetOptions struct {
+	selection           string
+	healthCheckInterval time.Duration
+	maxFailures         int
+}
+
+type replica struct {
+	db       *sql.DB
+	failures int
+	healthy  atomic.Bool
+}
+
+// replicaSet picks read replicas and ejects the ones failing consecutive health check
+// pings until they recover.
+type replicaSet struct {
+	replicas []*replica
+	options  replicaSetOptions
+	next     atomic.Uint64
+	done     chan struct{}
+	wg       sync.WaitGroup
+	closeErr error
+	closed   sync.Once
+}
+
+func newReplicaSet(dbs []*sql.DB, options replicaSetOptions) *replicaSet {
+	if options.maxFailures < 1 {
+		options.maxFailures = 1
+	}
+
+	set := &replicaSet{
+		replicas: make([]*replica, 0, len(dbs)),
+		options:  options,
+		done:     make(chan struct{}),
+	}
+	for _, db := range dbs {
+		r := &replica{db: db}
+		r.healthy.Store(true)
+		set.replicas = append(set.replicas, r)
+	}
+
+	if options.healthCheckInterval > 0 {
+		set.wg.Add(1)
+		go set.watch()
+	}
+
+	return set
+}
+
+// pick returns a healthy replica, nil when all of them are ejected.
+func (s *replicaSet) pick() *sql.DB {
+	if s.options.selection == config.ReplicaSelectionLeastConnections {
+		var picked *replica
+		for _, r := range s.replicas {
+			if !r.healthy.Load() {
+				continue
+			}
+			if picked == nil || r.db.Stats().InUse < picked.db.Stats().InUse {
+				picked = r
+			}
+		}
+		if picked == nil {
+			return nil
+		}
+		return picked.db
+	}
+
+	count := uint64(len(s.replicas))
+	start := s.next.Add(1) - 1
+	for i := uint64(0); i < count; i++ {
+		if r := s.replicas[(start+i)%count]; r.healthy.Load() {
+			return r.db
+		}
+	}
+
+	return nil
+}
+
+func (s *replicaSet) watch() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.options.healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.check()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// check pings every replica once. Only the watch goroutine updates failures.
+func (s *replicaSet) check() {
+	for _, r := range s.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), s.options.healthCheckInterval)
+		err := r.db.PingContext(ctx)
+		cancel()
+
+		if err == nil {
+			r.failures = 0
+			r.healthy.Store(true)
+			continue
+		}
+
+		r.failures++
+		if r.failures >= s.options.maxFailures {
+			r.healthy.Store(false)
+		}
+	}
+}
+
+// close stops the health checks and closes the replicas. Repeated calls return the
+// result of the first one.
+func (s *replicaSet) close() error {
+	s.closed.Do(func() {
+		close(s.done)
+		s.wg.Wait()
+
+		errs := make([]error, 0, len(s.replicas))
+		for _, r := range s.replicas {
+			errs = append(errs, r.db.Close())
+		}
+		s.closeErr = errors.Join(errs...)
+	})
+
+	return s.closeErr
+}
@@ -0,0 +1,161 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const defaultHealthCheckTimeout = 2 * time.Second
+
+// PoolStats is a sample of the statistics of one connection pool.
+type PoolStats struct {
+	// Database is the name of the SQLDBService.
+	Database string `json:"database"`
+
+	// Pool is "primary" or "replica-<n>", counting from 1.
+	Pool string `json:"pool"`
+
+	OpenConnections   int           `json:"open_connections"`
+	InUse             int           `json:"in_use"`
+	Idle              int           `json:"idle"`
+	WaitCount         int64         `json:"wait_count"`
+	WaitDuration      time.Duration `json:"wait_duration"`
+	MaxLifetimeClosed int64         `json:"max_lifetime_closed"`
+}
+
+// PoolStatsObserver receives the pool statistics sampled by SQLDBService.
+type PoolStatsObserver interface {
+	ObservePoolStats(ctx context.Context, stats PoolStats)
+}
+
+// PoolStatsObserverFunc adapts a function to a PoolStatsObserver.
+type PoolStatsObserverFunc func(ctx context.Context, stats PoolStats)
+
+func (f PoolStatsObserverFunc) ObservePoolStats(ctx context.Context, stats PoolStats) {
+	f(ctx, stats)
+}
+
+// GaugeRecorder is the part of a metrics registry used by the pool stats observer.
+type GaugeRecorder interface {
+	SetGauge(name string, value float64, labels map[string]string)
+}
+
+// NewPoolStatsMetrics records pool statistics as "sql_pool_*" gauges labeled by
+// database and pool.
+func NewPoolStatsMetrics(recorder GaugeRecorder) PoolStatsObserver {
+	return PoolStatsObserverFunc(func(_ context.Context, stats PoolStats) {
+		labels := map[string]string{"database": stats.Database, "pool": stats.Pool}
+		recorder.SetGauge("sql_pool_open_connections", float64(stats.OpenConnections), labels)
+		recorder.SetGauge("sql_pool_in_use_connections", float64(stats.InUse), labels)
+		recorder.SetGauge("sql_pool_idle_connections", float64(stats.Idle), labels)
+		recorder.SetGauge("sql_pool_wait_count", float64(stats.WaitCount), labels)
+		recorder.SetGauge("sql_pool_wait_duration_seconds", stats.WaitDuration.Seconds(), labels)
+		recorder.SetGauge("sql_pool_max_lifetime_closed", float64(stats.MaxLifetimeClosed), labels)
+	})
+}
+
+// NewPoolWaitLogger logs a warning when the time spent waiting for connections between
+// two samples of a pool reaches threshold, which usually means the pool is too small.
+func NewPoolWaitLogger(logger *slog.Logger, threshold time.Duration) PoolStatsObserver {
+	var mu sync.Mutex
+	previous := make(map[string]PoolStats)
+
+	return PoolStatsObserverFunc(func(ctx context.Context, stats PoolStats) {
+		mu.Lock()
+		last, ok := previous[stats.Database+"/"+stats.Pool]
+		previous[stats.Database+"/"+stats.Pool] = stats
+		mu.Unlock()
+		if !ok {
+			return
+		}
+
+		waited := stats.WaitDuration - last.WaitDuration
+		if waited < threshold {
+			return
+		}
+
+		logger.WarnContext(
+			ctx,
+			"SQL connection pool wait spike",
+			"database", stats.Database,
+			"pool", stats.Pool,
+			"waited", waited,
+			"waits", stats.WaitCount-last.WaitCount,
+			"open_connections", stats.OpenConnections,
+			"in_use", stats.InUse,
+		)
+	})
+}
+
+// Name returns the name of the database, "main" for the main database.
+func (d *SQLDBService) Name() string {
+	return d.name
+}
+
+// Stats returns the current statistics of the primary and the replica pools.
+func (d *SQLDBService) Stats() []PoolStats {
+	stats := []PoolStats{newPoolStats(d.name, "primary", d.db.Stats())}
+	if d.replicas != nil {
+		for i, r := range d.replicas.replicas {
+			stats = append(stats, newPoolStats(d.name, fmt.Sprintf("replica-%d", i+1), r.db.Stats()))
+		}
+	}
+
+	return stats
+}
+
+// HealthName implements HealthContributor.
+func (d *SQLDBService) HealthName() string {
+	return "db:" + d.name
+}
+
+// CheckHealth implements HealthContributor by pinging the primary, bounded by the
+// health check timeout of the service.
+func (d *SQLDBService) CheckHealth(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, d.healthTimeout)
+	defer cancel()
+
+	if err := d.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to ping %s database: %w", d.name, err)
+	}
+
+	return nil
+}
+
+// sampleStats reports the pool statistics to the observers every interval until stop
+// is closed.
+func (d *SQLDBService) sampleStats(interval time.Duration, observers []PoolStatsObserver) {
+	defer d.statsWG.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for _, stats := range d.Stats() {
+				for _, observer := range observers {
+					observer.ObservePoolStats(context.Background(), stats)
+				}
+			}
+		case <-d.stopStats:
+			return
+		}
+	}
+}
+
+func newPoolStats(database string, pool string, stats sql.DBStats) PoolStats {
+	return PoolStats{
+		Database:          database,
+		Pool:              pool,
+		OpenConnections:   stats.OpenConnections,
+		InUse:             stats.InUse,
+		Idle:              stats.Idle,
+		WaitCount:         stats.WaitCount,
+		WaitDuration:      stats.WaitDuration,
+		MaxLifetimeClosed: stats.MaxLifetimeClosed,
+	}
+}
@@ -0,0 +1,263 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golibry/go-web-skeleton/framework/config"
+)
+
+// testDriver opens connections supporting pings, which fail for DSNs marked down, and
+// transactions, whose statements are recorded.
+type testDriver struct {
+	mu         sync.Mutex
+	down       map[string]bool
+	statements []string
+	execErrors []error
+}
+
+var testSQLDriver = &testDriver{down: make(map[string]bool)}
+
+func init() {
+	sql.Register("apptest", testSQLDriver)
+}
+
+func (d *testDriver) setDown(dsn string, down bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.down[dsn] = down
+}
+
+func (d *testDriver) Open(dsn string) (driver.Conn, error) {
+	return &testConn{driver: d, dsn: dsn}, nil
+}
+
+type testConn struct {
+	driver *testDriver
+	dsn    string
+}
+
+func (c *testConn) Ping(context.Context) error {
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+	if c.driver.down[c.dsn] {
+		return errors.New("replica down")
+	}
+	return nil
+}
+
+func (c *testConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *testConn) Close() error {
+	return nil
+}
+
+func (c *testConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *testConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.driver.record("BEGIN")
+	return &testTx{driver: c.driver}, nil
+}
+
+// ExecContext records the statement and fails with the next queued error, if any.
+func (c *testConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.driver.record(query)
+
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+	if len(c.driver.execErrors) > 0 {
+		err := c.driver.execErrors[0]
+		c.driver.execErrors = c.driver.execErrors[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (d *testDriver) record(statement string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.statements = append(d.statements, statement)
+}
+
+// reset clears the recorded statements and queues errors for the next statements.
+func (d *testDriver) reset(execErrors ...error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.statements = nil
+	d.execErrors = execErrors
+}
+
+func (d *testDriver) recorded() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.statements...)
+}
+
+type testTx struct {
+	driver *testDriver
+}
+
+func (tx *testTx) Commit() error {
+	tx.driver.record("COMMIT")
+	return nil
+}
+
+func (tx *testTx) Rollback() error {
+	tx.driver.record("ROLLBACK")
+	return nil
+}
+
+func TestSQLDBServiceRoutesReadsToHealthyReplicas(t *testing.T) {
+	service, err := NewDBService(config.Database{
+		Driver:             "apptest",
+		Dsn:                "primary",
+		ReplicaDsns:        []string{"replica-1", "replica-2"},
+		ReplicaSelection:   config.ReplicaSelectionRoundRobin,
+		ReplicaMaxFailures: 2,
+		// Health checks are run by the test
+		ReplicaHealthCheckInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewDBService() error = %v", err)
+	}
+	defer func() { _ = service.Close() }()
+
+	first, second := service.replicas.replicas[0].db, service.replicas.replicas[1].db
+	if service.Reader(context.Background()) != first || service.Reader(context.Background()) != second {
+		t.Fatal("Reader() did not alternate between replicas")
+	}
+	if service.Reader(WithPrimary(context.Background())) != service.Writer() {
+		t.Fatal("Reader() ignored the forced primary")
+	}
+
+	testSQLDriver.setDown("replica-1", true)
+	defer testSQLDriver.setDown("replica-1", false)
+	service.replicas.check()
+	if service.replicas.replicas[0].healthy.Load() != true {
+		t.Fatal("replica was ejected before reaching the max failures")
+	}
+	service.replicas.check()
+	for i := 0; i < 3; i++ {
+		if service.Reader(context.Background()) != second {
+			t.Fatal("Reader() returned an ejected replica")
+		}
+	}
+
+	testSQLDriver.setDown("replica-2", true)
+	defer testSQLDriver.setDown("replica-2", false)
+	service.replicas.check()
+	service.replicas.check()
+	if service.Reader(context.Background()) != service.Writer() {
+		t.Fatal("Reader() did not fall back to the primary")
+	}
+
+	testSQLDriver.setDown("replica-1", false)
+	service.replicas.check()
+	if service.Reader(context.Background()) != first {
+		t.Fatal("recovered replica was not used again")
+	}
+}
+
+func TestSQLDBServiceCloseIsRepeatable(t *testing.T) {
+	service, err := NewDBService(config.Database{
+		Driver:                     "apptest",
+		Dsn:                        "primary",
+		ReplicaDsns:                []string{"replica-1"},
+		ReplicaHealthCheckInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewDBService() error = %v", err)
+	}
+
+	if err := service.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := service.Close(); err != nil {
+		t.Fatalf("second Close() error = %v", err)
+	}
+}
+
+func TestPoolWaitLoggerWarnsOnWaitSpikes(t *testing.T) {
+	output := &bytes.Buffer{}
+	observer := NewPoolWaitLogger(slog.New(slog.NewTextHandler(output, nil)), 100*time.Millisecond)
+
+	observer.ObservePoolStats(context.Background(), PoolStats{Database: "main", Pool: "primary"})
+	observer.ObservePoolStats(context.Background(), PoolStats{
+		Database: "main", Pool: "primary", WaitCount: 2, WaitDuration: 50 * time.Millisecond,
+	})
+	if output.Len() != 0 {
+		t.Fatalf("log = %q, want no warning below the threshold", output.String())
+	}
+
+	observer.ObservePoolStats(context.Background(), PoolStats{
+		Database: "main", Pool: "primary", WaitCount: 7, WaitDuration: 300 * time.Millisecond,
+	})
+	if !strings.Contains(output.String(), "waited=250ms waits=5") {
+		t.Fatalf("log = %q, want the wait spike", output.String())
+	}
+}
+
+func TestPingWithRetryWaitsForDatabase(t *testing.T) {
+	testSQLDriver.setDown("retry-primary", true)
+	defer testSQLDriver.setDown("retry-primary", false)
+
+	db, err := sql.Open("apptest", "retry-primary")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	time.AfterFunc(30*time.Millisecond, func() { testSQLDriver.setDown("retry-primary", false) })
+
+	output := &bytes.Buffer{}
+	err = PingWithRetry(context.Background(), db, ConnectRetryPolicy{
+		Timeout:        time.Second,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     20 * time.Millisecond,
+		Jitter:         0.2,
+	}, slog.New(slog.NewTextHandler(output, nil)))
+	if err != nil {
+		t.Fatalf("PingWithRetry() error = %v", err)
+	}
+	if !strings.Contains(output.String(), "Database not reachable, retrying") ||
+		!strings.Contains(output.String(), "Database is reachable") {
+		t.Fatalf("log = %q, want the retries and the recovery", output.String())
+	}
+}
+
+func TestPingWithRetryGivesUpAfterTimeout(t *testing.T) {
+	testSQLDriver.setDown("retry-down", true)
+	defer testSQLDriver.setDown("retry-down", false)
+
+	db, err := sql.Open("apptest", "retry-down")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	policy := ConnectRetryPolicy{Timeout: 50 * time.Millisecond, InitialBackoff: 10 * time.Millisecond}
+	err = PingWithRetry(context.Background(), db, policy, logger)
+	if err == nil || !strings.Contains(err.Error(), "database not reachable after") {
+		t.Fatalf("PingWithRetry() error = %v, want the retries exhausted", err)
+	}
+
+	err = PingWithRetry(context.Background(), db, ConnectRetryPolicy{}, logger)
+	if err == nil || strings.Contains(err.Error(), "attempts") {
+		t.Fatalf("PingWithRetry() error = %v, want the single ping error", err)
+	}
+}
@@ -0,0 +1,255 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrSavepointsUnsupported is returned by nested InTx calls on drivers without known
+// SAVEPOINT syntax.
+var ErrSavepointsUnsupported = errors.New("savepoints are not supported by the driver")
+
+// Querier is implemented by both *sql.DB and *sql.Tx, so repositories can run their
+// queries inside or outside a transaction.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// TxOptions configures a transaction started by InTx.
+type TxOptions struct {
+	Isolation sql.IsolationLevel
+	ReadOnly  bool
+
+	// Retry overrides the retry policy of the service.
+	Retry *TxRetryPolicy
+}
+
+// TxRetryPolicy decides when InTx runs the whole function again.
+type TxRetryPolicy struct {
+	// MaxAttempts is the total number of attempts, 1 disables retries.
+	MaxAttempts int
+
+	// Backoff is the wait before the first retry, doubled on every further retry up
+	// to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// Retryable reports whether an error is worth retrying, IsRetryableTxError when nil.
+	Retryable func(err error) bool
+}
+
+// DefaultTxRetryPolicy retries serialization failures and deadlocks twice.
+func DefaultTxRetryPolicy() TxRetryPolicy {
+	return TxRetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     10 * time.Millisecond,
+		MaxBackoff:  200 * time.Millisecond,
+		Retryable:   IsRetryableTxError,
+	}
+}
+
+// IsRetryableTxError reports whether err is a serialization failure or a deadlock on
+// MySQL, Postgres or SQLite.
+func IsRetryableTxError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	// Postgres drivers expose the SQLSTATE code
+	var stateErr interface{ SQLState() string }
+	if errors.As(err, &stateErr) {
+		switch stateErr.SQLState() {
+		case "40001", "40P01":
+			return true
+		}
+	}
+
+	message := err.Error()
+	for _, marker := range []string{
+		"Error 1213", // MySQL deadlock found when trying to get lock
+		"Error 1205", // MySQL lock wait timeout exceeded
+		"SQLSTATE 40001",
+		"SQLSTATE 40P01",
+		"database is locked",
+		"SQLITE_BUSY",
+	} {
+		if strings.Contains(message, marker) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type txContextKey struct{}
+
+// txState is the transaction stored in the context by InTx.
+type txState struct {
+	service    *SQLDBService
+	tx         *sql.Tx
+	savepoints int
+}
+
+// TxFromContext returns the transaction started by InTx that ctx belongs to.
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	state, ok := ctx.Value(txContextKey{}).(*txState)
+	if !ok {
+		return nil, false
+	}
+
+	return state.tx, true
+}
+
+// Querier returns the transaction of ctx started by this service, or the primary
+// connection pool outside a transaction.
+func (d *SQLDBService) Querier(ctx context.Context) Querier {
+	if state, ok := ctx.Value(txContextKey{}).(*txState); ok && state.service == d {
+		return state.tx
+	}
+
+	return d.db
+}
+
+// InTx runs fn in a transaction on the primary, committed when fn returns nil and
+// rolled back when it returns an error or panics. The transaction is stored in the
+// context passed to fn, so repositories join it through Querier or TxFromContext.
+//
+// A nested InTx call on a context already holding a transaction of the service runs
+// fn inside a savepoint instead, rolled back on its own on failure; opts are ignored
+// there. Failing attempts of the outermost call are retried as a whole according to
+// the retry policy, so fn must not have side effects outside the transaction.
+func (d *SQLDBService) InTx(
+	ctx context.Context,
+	opts *TxOptions,
+	fn func(ctx context.Context, tx *sql.Tx) error,
+) error {
+	if state, ok := ctx.Value(txContextKey{}).(*txState); ok && state.service == d {
+		return d.inSavepoint(ctx, state, fn)
+	}
+
+	if opts == nil {
+		opts = &TxOptions{}
+	}
+	policy := d.txRetry
+	if opts.Retry != nil {
+		policy = *opts.Retry
+	}
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = IsRetryableTxError
+	}
+
+	backoff := policy.Backoff
+	for attempt := 1; ; attempt++ {
+		err := d.runTx(ctx, opts, fn)
+		if err == nil || attempt >= policy.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, context.Cause(ctx))
+		case <-timer.C:
+		}
+
+		backoff *= 2
+		if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
+
+func (d *SQLDBService) runTx(
+	ctx context.Context,
+	opts *TxOptions,
+	fn func(ctx context.Context, tx *sql.Tx) error,
+) (err error) {
+	tx, err := d.db.BeginTx(ctx, &sql.TxOptions{Isolation: opts.Isolation, ReadOnly: opts.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			_ = tx.Rollback()
+			panic(recovered)
+		}
+	}()
+
+	txCtx := context.WithValue(ctx, txContextKey{}, &txState{service: d, tx: tx})
+	if err := fn(txCtx, tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
+			return errors.Join(err, fmt.Errorf("failed to roll back transaction: %w", rollbackErr))
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (d *SQLDBService) inSavepoint(
+	ctx context.Context,
+	state *txState,
+	fn func(ctx context.Context, tx *sql.Tx) error,
+) error {
+	if !supportsSavepoints(d.driver) {
+		return fmt.Errorf("%w: %q", ErrSavepointsUnsupported, d.driver)
+	}
+
+	state.savepoints++
+	name := fmt.Sprintf("sp_%d", state.savepoints)
+	if _, err := state.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	rollback := func() error {
+		if _, err := state.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); err != nil {
+			return fmt.Errorf("failed to roll back to savepoint: %w", err)
+		}
+		return nil
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			_ = rollback()
+			panic(recovered)
+		}
+	}()
+
+	if err := fn(ctx, state.tx); err != nil {
+		if rollbackErr := rollback(); rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+		return err
+	}
+
+	if _, err := state.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to release savepoint: %w", err)
+	}
+
+	return nil
+}
+
+// supportsSavepoints reports whether the driver is one of the MySQL, Postgres and SQLite
+// drivers, which share the SAVEPOINT, ROLLBACK TO SAVEPOINT and RELEASE SAVEPOINT syntax.
+func supportsSavepoints(driver string) bool {
+	switch driver {
+	case "mysql", "postgres", "pgx", "sqlite", "sqlite3":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/golibry/go-web-skeleton/framework/config"
+)
+
+func newTestTxService(t *testing.T) *SQLDBService {
+	t.Helper()
+
+	service, err := NewDBService(config.Database{Driver: "apptest", Dsn: "tx"})
+	if err != nil {
+		t.Fatalf("NewDBService() error = %v", err)
+	}
+	// The test driver accepts the shared SAVEPOINT syntax
+	service.driver = "sqlite"
+	service.txRetry.Backoff = 0
+	t.Cleanup(func() { _ = service.Close() })
+	testSQLDriver.reset()
+
+	return service
+}
+
+func TestInTxUsesSavepointsForNestedCalls(t *testing.T) {
+	service := newTestTxService(t)
+	failure := errors.New("nested failure")
+
+	err := service.InTx(context.Background(), nil, func(ctx context.Context, tx *sql.Tx) error {
+		if current, ok := TxFromContext(ctx); !ok || current != tx || service.Querier(ctx) != tx {
+			t.Fatal("transaction was not stored in the context")
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT 1"); err != nil {
+			return err
+		}
+
+		err := service.InTx(ctx, nil, func(ctx context.Context, tx *sql.Tx) error {
+			_, _ = tx.ExecContext(ctx, "INSERT 2")
+			return failure
+		})
+		if !errors.Is(err, failure) {
+			t.Fatalf("nested InTx() error = %v, want the nested failure", err)
+		}
+
+		return service.InTx(ctx, nil, func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "INSERT 3")
+			return err
+		})
+	})
+	if err != nil {
+		t.Fatalf("InTx() error = %v", err)
+	}
+
+	expected := []string{
+		"BEGIN",
+		"INSERT 1",
+		"SAVEPOINT sp_1", "INSERT 2", "ROLLBACK TO SAVEPOINT sp_1",
+		"SAVEPOINT sp_2", "INSERT 3", "RELEASE SAVEPOINT sp_2",
+		"COMMIT",
+	}
+	if statements := testSQLDriver.recorded(); !reflect.DeepEqual(statements, expected) {
+		t.Fatalf("statements = %v, want %v", statements, expected)
+	}
+}
+
+func TestInTxRollsBackOnPanic(t *testing.T) {
+	service := newTestTxService(t)
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("InTx() swallowed the panic")
+		}
+		if statements := testSQLDriver.recorded(); !reflect.DeepEqual(statements, []string{"BEGIN", "ROLLBACK"}) {
+			t.Fatalf("statements = %v, want BEGIN, ROLLBACK", statements)
+		}
+	}()
+
+	_ = service.InTx(context.Background(), nil, func(context.Context, *sql.Tx) error {
+		panic("boom")
+	})
+}
+
+func TestInTxRetriesRetryableErrors(t *testing.T) {
+	service := newTestTxService(t)
+	deadlock := errors.New("Error 1213 (40001): Deadlock found when trying to get lock")
+	testSQLDriver.reset(deadlock)
+
+	attempts := 0
+	err := service.InTx(context.Background(), nil, func(ctx context.Context, tx *sql.Tx) error {
+		attempts++
+		_, err := tx.ExecContext(ctx, "UPDATE")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("InTx() error = %v", err)
+	}
+	if attempts != 2 {
+		t.Fatalf("attempts = %d, want 2", attempts)
+	}
+
+	attempts = 0
+	failure := errors.New("constraint violation")
+	err = service.InTx(context.Background(), nil, func(context.Context, *sql.Tx) error {
+		attempts++
+		return failure
+	})
+	if !errors.Is(err, failure) || attempts != 1 {
+		t.Fatalf("InTx() = %v after %d attempts, want the failure after 1", err, attempts)
+	}
+}
@@ -0,0 +1,331 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultStreamWriteTimeout is the write deadline set before every stream write,
+	// extending the WriteTimeout of the server for long-lived responses.
+	DefaultStreamWriteTimeout = 30 * time.Second
+
+	// DefaultSSEHeartbeatInterval is how often SSE streams send a comment to keep the
+	// connection and the proxies in between from timing out.
+	DefaultSSEHeartbeatInterval = 15 * time.Second
+)
+
+var (
+	// ErrStreamingUnsupported is returned when the response writer cannot be flushed,
+	// e.g. because a middleware wrapping it does not implement Unwrap.
+	ErrStreamingUnsupported = errors.New("response streaming is not supported")
+
+	// ErrStreamClosed is returned by writes to a stream used after its handler returned.
+	ErrStreamClosed = errors.New("response stream is closed")
+)
+
+// StreamOptions configures streamed responses.
+type StreamOptions struct {
+	// WriteTimeout is the write deadline set before every write, DefaultStreamWriteTimeout
+	// when zero. A negative value keeps the deadline of the server.
+	WriteTimeout time.Duration
+}
+
+// SSEOptions configures a Server-Sent Events stream.
+type SSEOptions struct {
+	StreamOptions
+
+	// HeartbeatInterval is how often a comment is sent while idle,
+	// DefaultSSEHeartbeatInterval when zero. A negative value disables heartbeats.
+	HeartbeatInterval time.Duration
+
+	// Retry is sent to clients as the reconnection delay when positive.
+	Retry time.Duration
+}
+
+// SSEEvent is an event of a Server-Sent Events stream.
+type SSEEvent struct {
+	ID    string
+	Event string
+
+	// Data is sent as is when a string or []byte, otherwise encoded as JSON.
+	Data any
+
+	// Retry updates the reconnection delay of the client when positive.
+	Retry time.Duration
+}
+
+// streamWriter writes and flushes through an http.ResponseController, so the flushes
+// and write deadlines reach the connection through middleware unwrapping the writer.
+type streamWriter struct {
+	ctx          context.Context
+	w            http.ResponseWriter
+	controller   *http.ResponseController
+	writeTimeout time.Duration
+	mu           sync.Mutex
+	closed       bool
+}
+
+func newStreamWriter(w http.ResponseWriter, r *http.Request, options StreamOptions) *streamWriter {
+	if options.WriteTimeout == 0 {
+		options.WriteTimeout = DefaultStreamWriteTimeout
+	}
+
+	return &streamWriter{
+		ctx:          r.Context(),
+		w:            w,
+		controller:   http.NewResponseController(w),
+		writeTimeout: options.WriteTimeout,
+	}
+}
+
+// start writes the headers with status and flushes them.
+func (s *streamWriter) start(status int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.extendDeadline()
+	s.w.WriteHeader(status)
+	if err := s.controller.Flush(); err != nil {
+		if errors.Is(err, http.ErrNotSupported) {
+			return ErrStreamingUnsupported
+		}
+		return err
+	}
+
+	return nil
+}
+
+// write writes p and flushes it, failing once the client is gone.
+func (s *streamWriter) write(p []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrStreamClosed
+	}
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+
+	s.extendDeadline()
+	if _, err := s.w.Write(p); err != nil {
+		return err
+	}
+
+	return s.controller.Flush()
+}
+
+// close makes further writes fail, the response writer must not be used once the
+// handler returned.
+func (s *streamWriter) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+}
+
+func (s *streamWriter) extendDeadline() {
+	if s.writeTimeout > 0 {
+		// Writers without deadline support keep the server deadline.
+		_ = s.controller.SetWriteDeadline(time.Now().Add(s.writeTimeout))
+	}
+}
+
+// SSEStream is a Server-Sent Events response. It ends when the send function of SSE
+// returns or the client disconnects, which cancels Context.
+type SSEStream struct {
+	writer      *streamWriter
+	lastEventID string
+	stop        chan struct{}
+	done        sync.WaitGroup
+}
+
+// SSE starts a Server-Sent Events response and calls send with the stream, returning
+// its error. The heartbeats are stopped before SSE returns, so nothing is written to w
+// once the handler returns. The stream must not be used after send returns.
+func (rbs *ResponseBuilder) SSE(
+	w http.ResponseWriter,
+	r *http.Request,
+	options SSEOptions,
+	send func(stream *SSEStream) error,
+) error {
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+
+	stream := &SSEStream{
+		writer:      newStreamWriter(w, r, options.StreamOptions),
+		lastEventID: r.Header.Get("Last-Event-ID"),
+		stop:        make(chan struct{}),
+	}
+	defer stream.close()
+	if err := stream.writer.start(http.StatusOK); err != nil {
+		return err
+	}
+	if options.Retry > 0 {
+		if err := stream.writer.write([]byte(fmt.Sprintf("retry: %d\n\n", options.Retry.Milliseconds()))); err != nil {
+			return err
+		}
+	}
+
+	interval := options.HeartbeatInterval
+	if interval == 0 {
+		interval = DefaultSSEHeartbeatInterval
+	}
+	if interval > 0 {
+		stream.done.Add(1)
+		go stream.heartbeat(interval)
+	}
+
+	return send(stream)
+}
+
+// LastEventID returns the Last-Event-ID header of a reconnecting client, to resume
+// the stream after that event.
+func (s *SSEStream) LastEventID() string {
+	return s.lastEventID
+}
+
+// Context returns the request context, done once the client disconnects.
+func (s *SSEStream) Context() context.Context {
+	return s.writer.ctx
+}
+
+// Send writes event to the client.
+func (s *SSEStream) Send(event SSEEvent) error {
+	var data string
+	switch value := event.Data.(type) {
+	case nil:
+	case string:
+		data = value
+	case []byte:
+		data = string(value)
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to encode event data: %w", err)
+		}
+		data = string(encoded)
+	}
+
+	var b strings.Builder
+	if event.ID != "" {
+		writeSSEField(&b, "id", event.ID)
+	}
+	if event.Event != "" {
+		writeSSEField(&b, "event", event.Event)
+	}
+	if event.Retry > 0 {
+		writeSSEField(&b, "retry", strconv.FormatInt(event.Retry.Milliseconds(), 10))
+	}
+	for _, line := range strings.Split(data, "\n") {
+		writeSSEField(&b, "data", strings.TrimSuffix(line, "\r"))
+	}
+	b.WriteString("\n")
+
+	return s.writer.write([]byte(b.String()))
+}
+
+// close stops the heartbeats and makes further sends fail.
+func (s *SSEStream) close() {
+	close(s.stop)
+	s.done.Wait()
+	s.writer.close()
+}
+
+func (s *SSEStream) heartbeat(interval time.Duration) {
+	defer s.done.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.writer.write([]byte(": heartbeat\n\n")); err != nil {
+				return
+			}
+		case <-s.writer.ctx.Done():
+			return
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// writeSSEField writes a field line. Values must not contain line breaks, which
+// would end the field early, so they are dropped from IDs and event names.
+func writeSSEField(b *strings.Builder, name string, value string) {
+	if name != "data" {
+		value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+	}
+	b.WriteString(name)
+	b.WriteString(": ")
+	b.WriteString(value)
+	b.WriteString("\n")
+}
+
+// NDJSONStream is a newline delimited JSON response, writing one value per line.
+type NDJSONStream struct {
+	writer *streamWriter
+}
+
+// NDJSON starts a newline delimited JSON response with status, e.g. for large exports
+// written row by row.
+func (rbs *ResponseBuilder) NDJSON(
+	w http.ResponseWriter,
+	r *http.Request,
+	status int,
+	options StreamOptions,
+) (*NDJSONStream, error) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	stream := &NDJSONStream{writer: newStreamWriter(w, r, options)}
+	if err := stream.writer.start(status); err != nil {
+		return nil, err
+	}
+
+	return stream, nil
+}
+
+// Context returns the request context, done once the client disconnects.
+func (s *NDJSONStream) Context() context.Context {
+	return s.writer.ctx
+}
+
+// Write encodes v as a JSON line and flushes it.
+func (s *NDJSONStream) Write(v any) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode stream value: %w", err)
+	}
+
+	return s.writer.write(append(line, '\n'))
+}
+
+// WriteAll writes the values received from values until it is closed, the client
+// disconnects or a write fails.
+func (s *NDJSONStream) WriteAll(values <-chan any) error {
+	for {
+		select {
+		case v, ok := <-values:
+			if !ok {
+				return nil
+			}
+			if err := s.Write(v); err != nil {
+				return err
+			}
+		case <-s.writer.ctx.Done():
+			return s.writer.ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSSEStreamWritesEvents(t *testing.T) {
+	builder := NewResponseBuilderService(slog.New(slog.DiscardHandler), nil)
+	request := httptest.NewRequest(http.MethodGet, "/events", nil)
+	request.Header.Set("Last-Event-ID", "41")
+	recorder := httptest.NewRecorder()
+
+	var sent *SSEStream
+	err := builder.SSE(recorder, request, SSEOptions{HeartbeatInterval: -1, Retry: 3 * time.Second},
+		func(stream *SSEStream) error {
+			sent = stream
+			if stream.LastEventID() != "41" {
+				t.Errorf("LastEventID() = %q, want 41", stream.LastEventID())
+			}
+			if err := stream.Send(SSEEvent{ID: "42", Event: "order", Data: map[string]int{"id": 7}}); err != nil {
+				return err
+			}
+			return stream.Send(SSEEvent{Data: "line 1\nline 2"})
+		},
+	)
+	if err != nil {
+		t.Fatalf("SSE() error = %v", err)
+	}
+	if err := sent.Send(SSEEvent{Data: "after return"}); !errors.Is(err, ErrStreamClosed) {
+		t.Fatalf("Send() after return error = %v, want ErrStreamClosed", err)
+	}
+
+	want := "retry: 3000\n\n" +
+		"id: 42\nevent: order\ndata: {\"id\":7}\n\n" +
+		"data: line 1\ndata: line 2\n\n"
+	if recorder.Body.String() != want {
+		t.Fatalf("body = %q, want %q", recorder.Body.String(), want)
+	}
+	if recorder.Header().Get("Content-Type") != "text/event-stream" || !recorder.Flushed {
+		t.Fatalf("headers = %v, flushed = %v, want a flushed event stream", recorder.Header(), recorder.Flushed)
+	}
+}
+
+func TestSSEStreamSendsHeartbeatsUntilClientDisconnects(t *testing.T) {
+	builder := NewResponseBuilderService(slog.New(slog.DiscardHandler), nil)
+	handlerErr := make(chan error, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerErr <- builder.SSE(w, r, SSEOptions{HeartbeatInterval: 10 * time.Millisecond},
+			func(stream *SSEStream) error {
+				<-stream.Context().Done()
+				return stream.Send(SSEEvent{Data: "late"})
+			},
+		)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	line, err := bufio.NewReader(response.Body).ReadString('\n')
+	if err != nil || line != ": heartbeat\n" {
+		t.Fatalf("ReadString() = %q, %v, want a heartbeat", line, err)
+	}
+	cancel()
+
+	select {
+	case err := <-handlerErr:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("Send() error = %v, want the disconnect", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the handler did not notice the disconnect")
+	}
+}
+
+func TestNDJSONStreamWritesLines(t *testing.T) {
+	builder := NewResponseBuilderService(slog.New(slog.DiscardHandler), nil)
+	recorder := httptest.NewRecorder()
+
+	stream, err := builder.NDJSON(recorder, httptest.NewRequest(http.MethodGet, "/export", nil), http.StatusOK, StreamOptions{})
+	if err != nil {
+		t.Fatalf("NDJSON() error = %v", err)
+	}
+
+	values := make(chan any, 2)
+	values <- map[string]int{"id": 1}
+	values <- map[string]int{"id": 2}
+	close(values)
+	if err := stream.WriteAll(values); err != nil {
+		t.Fatalf("WriteAll() error = %v", err)
+	}
+
+	if recorder.Body.String() != "{\"id\":1}\n{\"id\":2}\n" {
+		t.Fatalf("body = %q, want a JSON line per value", recorder.Body.String())
+	}
+	if recorder.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("Content-Type = %q, want application/x-ndjson", recorder.Header().Get("Content-Type"))
+	}
+}
+
+// bufferingWriter hides the flusher of the writer it wraps, like middleware without
+// an Unwrap method.
+type bufferingWriter struct {
+	http.ResponseWriter
+}
+
+func TestStreamsRequireFlushableWriters(t *testing.T) {
+	builder := NewResponseBuilderService(slog.New(slog.DiscardHandler), nil)
+	writer := bufferingWriter{httptest.NewRecorder()}
+
+	_, err := builder.NDJSON(writer, httptest.NewRequest(http.MethodGet, "/export", nil), http.StatusOK, StreamOptions{})
+	if !errors.Is(err, ErrStreamingUnsupported) {
+		t.Fatalf("NDJSON() error = %v, want ErrStreamingUnsupported", err)
+	}
+}
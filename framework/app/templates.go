@@ -0,0 +1,365 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTemplatesDir is the directory of the templates under the AppBaseDir.
+	DefaultTemplatesDir = "templates"
+
+	// DefaultTemplatesReloadInterval is the minimum time between two checks of the
+	// template files for changes.
+	DefaultTemplatesReloadInterval = time.Second
+
+	// DefaultCSRFCookieName is the cookie holding the token of the CSRF middleware.
+	DefaultCSRFCookieName = "csrf_token"
+)
+
+// ErrTemplateNotFound is returned when rendering a page that does not exist.
+var ErrTemplateNotFound = errors.New("template not found")
+
+// TemplateOptions configures a TemplateEngine.
+type TemplateOptions struct {
+	// Dir is the directory of the templates, used when FS is nil.
+	Dir string
+
+	// FS holds the templates instead of Dir, e.g. an embed.FS in production.
+	FS fs.FS
+
+	// LayoutsDir and PartialsDir are the directories of the templates shared by every
+	// page, "layouts" and "partials" by default. Every other file is a page.
+	LayoutsDir  string
+	PartialsDir string
+
+	// Extension of the template files, ".html" by default.
+	Extension string
+
+	// Funcs are available to every template.
+	Funcs template.FuncMap
+
+	// Reload re-parses the templates when a file changes, for local development.
+	Reload bool
+
+	// ReloadInterval is the minimum time between two checks for changes,
+	// DefaultTemplatesReloadInterval by default.
+	ReloadInterval time.Duration
+
+	// CSRFToken returns the CSRF token of a request, exposed as .CSRFToken. It reads
+	// the DefaultCSRFCookieName cookie set by the CSRF middleware by default, which is
+	// missing on the first request of a client.
+	CSRFToken func(r *http.Request) string
+
+	// RequestData returns values of a request exposed as .Values, e.g. the current user.
+	RequestData func(r *http.Request) map[string]any
+}
+
+// TemplateView is the data pages are executed with.
+type TemplateView struct {
+	// Data is the value passed to Render.
+	Data      any
+	Request   *http.Request
+	RequestID string
+	CSRFToken string
+	Values    map[string]any
+}
+
+// TemplateEngine renders html/template pages with the layouts and partials shared by
+// all of them.
+//
+// Pages are named after their path without extension, e.g. "orders/list" for
+// orders/list.html, and are parsed with every layout and partial, named the same way.
+// A page usually executes a layout defining blocks it overrides:
+//
+//	{{template "layouts/base" .}}
+//	{{define "content"}}...{{end}}
+type TemplateEngine struct {
+	options TemplateOptions
+	fsys    fs.FS
+
+	mu      sync.RWMutex
+	pages   map[string]*template.Template
+	version templatesVersion
+
+	checkMu sync.Mutex
+	checked time.Time
+}
+
+// templatesVersion identifies the state of the template files for reloading.
+type templatesVersion struct {
+	files   int
+	size    int64
+	modTime time.Time
+}
+
+// NewTemplateEngine parses the templates of options.
+func NewTemplateEngine(options TemplateOptions) (*TemplateEngine, error) {
+	if options.LayoutsDir == "" {
+		options.LayoutsDir = "layouts"
+	}
+	if options.PartialsDir == "" {
+		options.PartialsDir = "partials"
+	}
+	if options.Extension == "" {
+		options.Extension = ".html"
+	}
+	if options.ReloadInterval <= 0 {
+		options.ReloadInterval = DefaultTemplatesReloadInterval
+	}
+	if options.CSRFToken == nil {
+		options.CSRFToken = CSRFTokenFromCookie(DefaultCSRFCookieName)
+	}
+
+	engine := &TemplateEngine{options: options, fsys: options.FS}
+	if engine.fsys == nil {
+		if options.Dir == "" {
+			return nil, errors.New("missing templates directory")
+		}
+		engine.fsys = os.DirFS(options.Dir)
+	}
+
+	if err := engine.load(); err != nil {
+		return nil, err
+	}
+
+	return engine, nil
+}
+
+// Render executes the page name with data and writes it as an HTML response with
+// status. The page is executed before writing, so errors leave the response untouched.
+func (e *TemplateEngine) Render(
+	w http.ResponseWriter,
+	r *http.Request,
+	status int,
+	name string,
+	data any,
+) error {
+	buf := &bytes.Buffer{}
+	if err := e.Execute(buf, r, name, data); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// CSRFTokenFromCookie returns a TemplateOptions.CSRFToken reading the token from the
+// cookie name, as set by the CSRF middleware.
+func CSRFTokenFromCookie(name string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			return ""
+		}
+		return cookie.Value
+	}
+}
+
+// Execute executes the page name with a TemplateView of data and the request, which
+// may be nil.
+func (e *TemplateEngine) Execute(w io.Writer, r *http.Request, name string, data any) error {
+	page, err := e.page(name)
+	if err != nil {
+		return err
+	}
+
+	if err := page.ExecuteTemplate(w, name, newTemplateView(r, data, e.options)); err != nil {
+		return fmt.Errorf("failed to execute template %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// Has reports whether the page name exists.
+func (e *TemplateEngine) Has(name string) bool {
+	_, err := e.page(name)
+	return err == nil
+}
+
+// ContentType implements Encoder, rendering the values implementing TemplateNamer
+// with ResponseBuilder.Negotiate.
+func (e *TemplateEngine) ContentType() string {
+	return "text/html; charset=utf-8"
+}
+
+// CanEncode implements Encoder.
+func (e *TemplateEngine) CanEncode(v any) bool {
+	namer, ok := v.(TemplateNamer)
+	return ok && e.Has(namer.TemplateName())
+}
+
+// Encode implements Encoder. Pages rendered this way get no request data.
+func (e *TemplateEngine) Encode(w io.Writer, v any) error {
+	return e.EncodeRequest(w, nil, v)
+}
+
+// EncodeRequest implements RequestEncoder, so negotiated pages get the request data.
+func (e *TemplateEngine) EncodeRequest(w io.Writer, r *http.Request, v any) error {
+	return e.Execute(w, r, v.(TemplateNamer).TemplateName(), v)
+}
+
+// newTemplateView returns the view of data for the request, which may be nil.
+func newTemplateView(r *http.Request, data any, options TemplateOptions) TemplateView {
+	view := TemplateView{Data: data, Request: r}
+	if r == nil {
+		return view
+	}
+
+	view.RequestID = RequestIDFromContext(r.Context())
+	if options.CSRFToken != nil {
+		view.CSRFToken = options.CSRFToken(r)
+	}
+	if options.RequestData != nil {
+		view.Values = options.RequestData(r)
+	}
+
+	return view
+}
+
+func (e *TemplateEngine) page(name string) (*template.Template, error) {
+	if e.options.Reload {
+		if err := e.reloadIfChanged(); err != nil {
+			return nil, err
+		}
+	}
+
+	e.mu.RLock()
+	page, ok := e.pages[name]
+	e.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+
+	return page, nil
+}
+
+// reloadIfChanged re-parses the templates when they changed, checking at most once per
+// ReloadInterval so requests rendering several pages do not scan the files each time.
+func (e *TemplateEngine) reloadIfChanged() error {
+	e.checkMu.Lock()
+	defer e.checkMu.Unlock()
+
+	now := time.Now()
+	if now.Sub(e.checked) < e.options.ReloadInterval {
+		return nil
+	}
+	e.checked = now
+
+	version, err := e.scan()
+	if err != nil {
+		return err
+	}
+
+	e.mu.RLock()
+	changed := version != e.version
+	e.mu.RUnlock()
+	if !changed {
+		return nil
+	}
+
+	return e.load()
+}
+
+// load parses every page with the shared templates.
+func (e *TemplateEngine) load() error {
+	version, err := e.scan()
+	if err != nil {
+		return err
+	}
+
+	shared := template.New("").Funcs(e.options.Funcs)
+	var pageFiles []string
+	err = fs.WalkDir(e.fsys, ".", func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(file) != e.options.Extension {
+			return err
+		}
+		if !e.isShared(file) {
+			pageFiles = append(pageFiles, file)
+			return nil
+		}
+
+		return e.parseFile(shared, file)
+	})
+	if err != nil {
+		return err
+	}
+
+	pages := make(map[string]*template.Template, len(pageFiles))
+	for _, file := range pageFiles {
+		page, err := shared.Clone()
+		if err != nil {
+			return fmt.Errorf("failed to clone templates: %w", err)
+		}
+		if err := e.parseFile(page, file); err != nil {
+			return err
+		}
+		pages[e.templateName(file)] = page
+	}
+
+	e.mu.Lock()
+	e.pages = pages
+	e.version = version
+	e.mu.Unlock()
+
+	return nil
+}
+
+func (e *TemplateEngine) parseFile(set *template.Template, file string) error {
+	content, err := fs.ReadFile(e.fsys, file)
+	if err != nil {
+		return fmt.Errorf("failed to read template %s: %w", file, err)
+	}
+	if _, err := set.New(e.templateName(file)).Parse(string(content)); err != nil {
+		return fmt.Errorf("failed to parse template %s: %w", file, err)
+	}
+
+	return nil
+}
+
+func (e *TemplateEngine) isShared(file string) bool {
+	return strings.HasPrefix(file, e.options.LayoutsDir+"/") ||
+		strings.HasPrefix(file, e.options.PartialsDir+"/")
+}
+
+func (e *TemplateEngine) templateName(file string) string {
+	return strings.TrimSuffix(file, e.options.Extension)
+}
+
+// scan returns the version of the template files.
+func (e *TemplateEngine) scan() (templatesVersion, error) {
+	var version templatesVersion
+	err := fs.WalkDir(e.fsys, ".", func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(file) != e.options.Extension {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		version.files++
+		version.size += info.Size()
+		if info.ModTime().After(version.modTime) {
+			version.modTime = info.ModTime()
+		}
+		return nil
+	})
+	if err != nil {
+		return templatesVersion{}, fmt.Errorf("failed to scan templates: %w", err)
+	}
+
+	return version, nil
+}
@@ -0,0 +1,158 @@
+package app
+
+import (
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func writeTemplate(t *testing.T, dir string, name string, content string) {
+	t.Helper()
+
+	file := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func TestTemplateEngineRendersPagesWithLayoutsAndPartials(t *testing.T) {
+	templates := fstest.MapFS{
+		"layouts/base.html":  {Data: []byte(`<title>{{block "title" .}}App{{end}}</title>{{template "content" .}}`)},
+		"partials/csrf.html": {Data: []byte(`{{define "csrf"}}<input name="csrf" value="{{.CSRFToken}}">{{end}}`)},
+		"orders/list.html": {Data: []byte(
+			`{{template "layouts/base" .}}{{define "title"}}Orders{{end}}` +
+				`{{define "content"}}{{range .Data}}<p>{{upper .}}</p>{{end}}{{template "csrf" .}}` +
+				`{{.Values.user}}{{end}}`,
+		)},
+		"home.html": {Data: []byte(`{{template "layouts/base" .}}{{define "content"}}home{{end}}`)},
+	}
+	engine, err := NewTemplateEngine(TemplateOptions{
+		FS:          templates,
+		Funcs:       template.FuncMap{"upper": strings.ToUpper},
+		CSRFToken:   func(*http.Request) string { return "token-1" },
+		RequestData: func(*http.Request) map[string]any { return map[string]any{"user": "ana"} },
+	})
+	if err != nil {
+		t.Fatalf("NewTemplateEngine() error = %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	if err := engine.Render(recorder, request, http.StatusOK, "orders/list", []string{"a", "b"}); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	want := `<title>Orders</title><p>A</p><p>B</p><input name="csrf" value="token-1">ana`
+	if recorder.Body.String() != want {
+		t.Fatalf("body = %q, want %q", recorder.Body.String(), want)
+	}
+	if recorder.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Fatalf("Content-Type = %q, want text/html", recorder.Header().Get("Content-Type"))
+	}
+
+	// Pages are parsed separately, so their blocks do not override each other.
+	recorder = httptest.NewRecorder()
+	if err := engine.Render(recorder, request, http.StatusOK, "home", nil); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if recorder.Body.String() != "<title>App</title>home" {
+		t.Fatalf("body = %q, want the home page", recorder.Body.String())
+	}
+
+	err = engine.Render(httptest.NewRecorder(), request, http.StatusOK, "layouts/base", nil)
+	if !errors.Is(err, ErrTemplateNotFound) {
+		t.Fatalf("Render() error = %v, want ErrTemplateNotFound for layouts", err)
+	}
+}
+
+func TestTemplateEngineReloadsChangedTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "home.html", "v1")
+
+	reloading, err := NewTemplateEngine(TemplateOptions{Dir: dir, Reload: true})
+	if err != nil {
+		t.Fatalf("NewTemplateEngine() error = %v", err)
+	}
+	static, err := NewTemplateEngine(TemplateOptions{Dir: dir})
+	if err != nil {
+		t.Fatalf("NewTemplateEngine() error = %v", err)
+	}
+
+	writeTemplate(t, dir, "home.html", "version 2")
+	writeTemplate(t, dir, "about.html", "about")
+	future := time.Now().Add(time.Minute)
+	_ = os.Chtimes(filepath.Join(dir, "home.html"), future, future)
+
+	body := &strings.Builder{}
+	if err := reloading.Execute(body, nil, "home", nil); err != nil || body.String() != "version 2" {
+		t.Fatalf("Execute() = %q, %v, want the changed template", body.String(), err)
+	}
+	if !reloading.Has("about") {
+		t.Fatal("Has(about) = false, want the added template")
+	}
+
+	body.Reset()
+	if err := static.Execute(body, nil, "home", nil); err != nil || body.String() != "v1" {
+		t.Fatalf("Execute() = %q, %v, want the parsed template without reload", body.String(), err)
+	}
+}
+
+func TestTemplateEngineChecksForChangesOncePerInterval(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "home.html", "v1")
+
+	engine, err := NewTemplateEngine(TemplateOptions{Dir: dir, Reload: true, ReloadInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewTemplateEngine() error = %v", err)
+	}
+	if !engine.Has("home") {
+		t.Fatal("Has(home) = false, want the parsed template")
+	}
+
+	writeTemplate(t, dir, "home.html", "version 2")
+	future := time.Now().Add(time.Minute)
+	_ = os.Chtimes(filepath.Join(dir, "home.html"), future, future)
+
+	body := &strings.Builder{}
+	if err := engine.Execute(body, nil, "home", nil); err != nil || body.String() != "v1" {
+		t.Fatalf("Execute() = %q, %v, want the template of the last check", body.String(), err)
+	}
+}
+
+func TestTemplateEngineEncodesNegotiatedPages(t *testing.T) {
+	engine, err := NewTemplateEngine(TemplateOptions{
+		FS: fstest.MapFS{"page.html": {Data: []byte(
+			`<h1>{{.Data.Title}}</h1><input name="csrf" value="{{.CSRFToken}}">{{.RequestID}}`,
+		)}},
+	})
+	if err != nil {
+		t.Fatalf("NewTemplateEngine() error = %v", err)
+	}
+	builder := NewResponseBuilderService(slog.New(slog.DiscardHandler), nil)
+	builder.RegisterEncoder(engine)
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request = request.WithContext(WithRequestID(request.Context(), "req-1"))
+	request.Header.Set("Accept", "text/html")
+	request.AddCookie(&http.Cookie{Name: DefaultCSRFCookieName, Value: "token-1"})
+	recorder := httptest.NewRecorder()
+	if err := builder.Negotiate(recorder, request, http.StatusOK, negotiatePage{Title: "Home"}); err != nil {
+		t.Fatalf("Negotiate() error = %v", err)
+	}
+
+	want := `<h1>Home</h1><input name="csrf" value="token-1">req-1`
+	if recorder.Body.String() != want {
+		t.Fatalf("body = %q, want %q", recorder.Body.String(), want)
+	}
+}
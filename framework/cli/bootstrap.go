@@ -1,11 +1,13 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
 
 	"github.com/golibry/go-cli-command/cli"
+	"github.com/golibry/go-web-skeleton/framework/app"
 )
 
 func NewRegistry(commands []cli.Command) (*cli.CommandsRegistry, error) {
@@ -20,6 +22,11 @@ func NewRegistry(commands []cli.Command) (*cli.CommandsRegistry, error) {
 	return commandRegistry, nil
 }
 
+// Bootstrap runs the command selected by the process arguments. Commands derive their
+// contexts from app.BaseContext, which is canceled on shutdown signals and tags the logs
+// with the command id, so no command handles signals on its own. Only http:start runs
+// the app lifecycle hooks, other commands, e.g. migrations, must not start the workers
+// and consumers of the app.
 func Bootstrap(
 	logger *slog.Logger,
 	availableCommands []cli.Command,
@@ -30,7 +37,35 @@ func Bootstrap(
 		os.Exit(1)
 	}
 
+	ctx, stop := app.SignalContext(context.Background())
+	defer stop()
+	if id := commandId(os.Args[1:], availableCommands); id != "" {
+		ctx = app.WithCommand(ctx, id)
+	}
+	app.SetBaseContext(ctx)
+
 	// Bootstrap and run the CLI application
 	// os.Args[1: ] is mandatory to remove the program name from the args slice
 	cli.Bootstrap(os.Args[1:], commandRegistry, os.Stdout, os.Exit)
 }
+
+// commandId returns the id of the command to run, the first argument that is the id of
+// a command, e.g. "migrations:up" of "-env prod migrations:up". Matching the registered
+// ids skips the values of flags given before the command.
+func commandId(args []string, commands []cli.Command) string {
+	ids := make(map[string]struct{}, len(commands))
+	for _, cmd := range commands {
+		ids[cmd.Id()] = struct{}{}
+	}
+
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if _, ok := ids[arg]; ok {
+			return arg
+		}
+	}
+
+	return ""
+}
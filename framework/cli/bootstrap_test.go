@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"io"
+	"testing"
+
+	"github.com/golibry/go-cli-command/cli"
+)
+
+type testCommand struct {
+	cli.CommandWithoutFlags
+	id string
+}
+
+func (c *testCommand) Id() string {
+	return c.id
+}
+
+func (c *testCommand) Description() string {
+	return "Test command"
+}
+
+func (c *testCommand) Exec(_ io.Writer) error {
+	return nil
+}
+
+func TestCommandIdSkipsFlagValues(t *testing.T) {
+	commands := []cli.Command{&testCommand{id: "migrations"}, &testCommand{id: "http:start"}}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"http:start"}, want: "http:start"},
+		{args: []string{"-v", "migrations", "up"}, want: "migrations"},
+		{args: []string{"-env", "prod", "migrations", "up"}, want: "migrations"},
+		{args: []string{"-env=prod", "http:start"}, want: "http:start"},
+		{args: []string{"--", "http:start"}, want: ""},
+		{args: []string{"unknown"}, want: ""},
+	}
+	for _, tt := range tests {
+		if got := commandId(tt.args, commands); got != tt.want {
+			t.Errorf("commandId(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
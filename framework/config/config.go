@@ -29,9 +29,9 @@ func BuildCustom[T AppProvider](composite T) error {
 	}
 
 	setDefaultLocalDevAppBaseDir(app)
-	validate := validator.New(validator.WithRequiredStructEnabled())
-	if err := RegisterLogValidator(validate); err != nil {
-		return fmt.Errorf("failed to register custom validators: %w", err)
+	validate, err := NewValidator()
+	if err != nil {
+		return err
 	}
 
 	loader := goconfig.NewLoaderWithValidator(validate)
@@ -45,6 +45,17 @@ func BuildCustom[T AppProvider](composite T) error {
 	return nil
 }
 
+// NewValidator returns a validator with the custom validation functions of the
+// framework registered, e.g. "logpath".
+func NewValidator() (*validator.Validate, error) {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	if err := RegisterLogValidator(validate); err != nil {
+		return nil, fmt.Errorf("failed to register custom validators: %w", err)
+	}
+
+	return validate, nil
+}
+
 // DebugAny returns a masked debug string for any config struct.
 func DebugAny(v interface{}) string {
 	return goconfig.Debug(v)
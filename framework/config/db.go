@@ -1,19 +1,33 @@
 package config
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/golibry/go-params/params"
 )
 
+const (
+	ReplicaSelectionRoundRobin       = "round-robin"
+	ReplicaSelectionLeastConnections = "least-connections"
+)
+
+// defaultExecutionsTable is the migrations executions table of the main database, named
+// databases default to defaultExecutionsTable_<name>.
+const defaultExecutionsTable = "migrations_executions"
+
 type Migrations struct {
 	// MigrationsDirPath specifies the directory path containing database migration files.
 	// This must be a valid directory path and is required for database migrations.
 	MigrationsDirPath string `env:"DB_MIGRATIONS_DIR_PATH" validate:"required,dir"`
 
 	// ExecutionsTable specifies the name of the table that stores migration execution information.
-	ExecutionsTable string `env:"DB_MIGRATIONS_TABLE" default:"migrations_executions" validate:"required"`
+	ExecutionsTable string `env:"DB_MIGRATIONS_TABLE" validate:"required"`
 }
 
 // Populate implements the go-config Config interface for Database.
@@ -26,7 +40,7 @@ func (d *Migrations) Populate() error {
 		defaultMigrationsDirPath,
 	)
 	executionsTable, _ := params.GetEnvAsString(
-		"DB_MIGRATIONS_TABLE", "migrations_executions",
+		"DB_MIGRATIONS_TABLE", defaultExecutionsTable,
 	)
 
 	d.MigrationsDirPath = migrationsDirPath
@@ -36,6 +50,7 @@ func (d *Migrations) Populate() error {
 
 // Database contains database connection and pool configuration settings.
 // It defines how the application connects to and manages database connections.
+// The defaults are set by Populate only, the env tags name the variables of the main database.
 type Database struct {
 	// Name is the configured application database name.
 	Name string `env:"DB_NAME" validate:"required"`
@@ -48,23 +63,201 @@ type Database struct {
 	Dsn string `env:"DB_DSN" validate:"required"`
 
 	// The database driver to use for connecting to the database (e.g., "mysql", "postgres", "sqlite").
-	Driver string `env:"DB_DRIVER" default:"mysql" validate:"required"`
+	Driver string `env:"DB_DRIVER" validate:"required"`
 
 	// MaxIdleConnections sets the maximum number of connections in the idle connection pool.
 	// Must be between 0 and 99. A value of 0 means no idle connections are retained.
-	MaxIdleConnections int `env:"DB_MAX_IDLE_CONNECTIONS" default:"2" validate:"number,gte=0,lte=99"`
+	MaxIdleConnections int `env:"DB_MAX_IDLE_CONNECTIONS" validate:"number,gte=0,lte=99"`
 
 	// MaxOpenConnections sets the maximum number of open connections to the database.
 	// Must be between 0 and 99. A value of 0 means unlimited connections.
-	MaxOpenConnections int `env:"DB_MAX_OPEN_CONNECTIONS" default:"10" validate:"number,gte=0,lte=99"`
+	MaxOpenConnections int `env:"DB_MAX_OPEN_CONNECTIONS" validate:"number,gte=0,lte=99"`
 
 	// ConnectionMaxIdleTime sets the maximum amount of time a connection may be idle.
 	// Expired connections may be closed lazily before reuse.
-	ConnectionMaxIdleTime time.Duration `env:"DB_CONNECTION_MAX_IDLE_TIME" default:"3m"`
+	ConnectionMaxIdleTime time.Duration `env:"DB_CONNECTION_MAX_IDLE_TIME"`
 
 	// ConnectionMaxLifetime sets the maximum amount of time a connection may be reused.
 	// Expired connections may be closed lazily before reuse.
-	ConnectionMaxLifetime time.Duration `env:"DB_CONNECTION_MAX_LIFETIME" default:"3m"`
+	ConnectionMaxLifetime time.Duration `env:"DB_CONNECTION_MAX_LIFETIME"`
+
+	// ReplicaDsns lists the DSNs of read replicas, using the same driver and pool settings
+	// as the primary. It is read from DB_REPLICA_DSNS as a comma-separated list.
+	ReplicaDsns []string
+
+	// ReplicaSelection is how a replica is picked for reads.
+	// Valid values are: "round-robin" and "least-connections" (fewest in-use connections).
+	ReplicaSelection string `env:"DB_REPLICA_SELECTION" validate:"oneof=round-robin least-connections"`
+
+	// ReplicaHealthCheckInterval is how often replicas are pinged.
+	// A value of 0 disables health checks, so replicas are never ejected.
+	ReplicaHealthCheckInterval time.Duration `env:"DB_REPLICA_HEALTH_CHECK_INTERVAL"`
+
+	// ReplicaMaxFailures is the number of consecutive failed pings after which a replica
+	// stops receiving reads, until a ping succeeds again.
+	ReplicaMaxFailures int `env:"DB_REPLICA_MAX_FAILURES" validate:"gte=1"`
+
+	// SlowQueryThreshold is the duration from which queries are logged as slow.
+	// A value of 0 disables the slow query log.
+	SlowQueryThreshold time.Duration `env:"DB_SLOW_QUERY_THRESHOLD"`
+
+	// StatsInterval is how often the connection pool statistics are sampled.
+	// A value of 0 disables sampling.
+	StatsInterval time.Duration `env:"DB_STATS_INTERVAL"`
+
+	// PoolWaitWarnThreshold is the time spent waiting for connections between two
+	// samples from which a warning is logged. A value of 0 disables the warning.
+	PoolWaitWarnThreshold time.Duration `env:"DB_POOL_WAIT_WARN_THRESHOLD"`
+
+	// ConnectTimeout is the total time allowed for reaching the database on startup,
+	// retrying failed pings with backoff. A value of 0 pings once.
+	ConnectTimeout time.Duration `env:"DB_CONNECT_TIMEOUT"`
+
+	// ConnectRetryBackoff is the wait after the first failed startup ping, doubled after
+	// every further failure up to ConnectRetryMaxBackoff.
+	ConnectRetryBackoff    time.Duration `env:"DB_CONNECT_RETRY_BACKOFF"`
+	ConnectRetryMaxBackoff time.Duration `env:"DB_CONNECT_RETRY_MAX_BACKOFF"`
 
 	Migrations Migrations `validate:"required"`
 }
+
+// Populate implements the go-config Config interface for Database.
+// It reads values from environment variables providing sensible defaults.
+func (d *Database) Populate() error {
+	d.populate("DB_", "")
+	return d.Migrations.Populate()
+}
+
+// populate reads the connection, pool, read replica, instrumentation and startup retry
+// settings from environment variables with the given prefix.
+func (d *Database) populate(prefix string, defaultName string) {
+	name, _ := params.GetEnvAsString(prefix+"NAME", defaultName)
+	dsn, _ := params.GetEnvAsString(prefix+"DSN", "")
+	driver, _ := params.GetEnvAsString(prefix+"DRIVER", "mysql")
+	maxIdleConnections, _ := params.GetEnvAsInt(prefix+"MAX_IDLE_CONNECTIONS", 2)
+	maxOpenConnections, _ := params.GetEnvAsInt(prefix+"MAX_OPEN_CONNECTIONS", 10)
+	connectionMaxIdleTime, _ := params.GetEnvAsDuration(
+		prefix+"CONNECTION_MAX_IDLE_TIME",
+		3*time.Minute,
+	)
+	connectionMaxLifetime, _ := params.GetEnvAsDuration(
+		prefix+"CONNECTION_MAX_LIFETIME",
+		3*time.Minute,
+	)
+	replicaDsns, _ := params.GetEnvAsString(prefix+"REPLICA_DSNS", "")
+	replicaSelection, _ := params.GetEnvAsString(prefix+"REPLICA_SELECTION", ReplicaSelectionRoundRobin)
+	replicaHealthCheckInterval, _ := params.GetEnvAsDuration(
+		prefix+"REPLICA_HEALTH_CHECK_INTERVAL",
+		10*time.Second,
+	)
+	replicaMaxFailures, _ := params.GetEnvAsInt(prefix+"REPLICA_MAX_FAILURES", 3)
+	slowQueryThreshold, _ := params.GetEnvAsDuration(prefix+"SLOW_QUERY_THRESHOLD", 0)
+	statsInterval, _ := params.GetEnvAsDuration(prefix+"STATS_INTERVAL", 0)
+	poolWaitWarnThreshold, _ := params.GetEnvAsDuration(prefix+"POOL_WAIT_WARN_THRESHOLD", 0)
+	connectTimeout, _ := params.GetEnvAsDuration(prefix+"CONNECT_TIMEOUT", 30*time.Second)
+	connectRetryBackoff, _ := params.GetEnvAsDuration(
+		prefix+"CONNECT_RETRY_BACKOFF",
+		500*time.Millisecond,
+	)
+	connectRetryMaxBackoff, _ := params.GetEnvAsDuration(
+		prefix+"CONNECT_RETRY_MAX_BACKOFF",
+		5*time.Second,
+	)
+
+	d.Name = name
+	d.Dsn = dsn
+	d.Driver = driver
+	d.MaxIdleConnections = maxIdleConnections
+	d.MaxOpenConnections = maxOpenConnections
+	d.ConnectionMaxIdleTime = connectionMaxIdleTime
+	d.ConnectionMaxLifetime = connectionMaxLifetime
+	d.ReplicaDsns = make([]string, 0)
+	for _, dsn := range strings.Split(replicaDsns, ",") {
+		if dsn = strings.TrimSpace(dsn); dsn != "" {
+			d.ReplicaDsns = append(d.ReplicaDsns, dsn)
+		}
+	}
+	d.ReplicaSelection = replicaSelection
+	d.ReplicaHealthCheckInterval = replicaHealthCheckInterval
+	d.ReplicaMaxFailures = replicaMaxFailures
+	d.SlowQueryThreshold = slowQueryThreshold
+	d.StatsInterval = statsInterval
+	d.PoolWaitWarnThreshold = poolWaitWarnThreshold
+	d.ConnectTimeout = connectTimeout
+	d.ConnectRetryBackoff = connectRetryBackoff
+	d.ConnectRetryMaxBackoff = connectRetryMaxBackoff
+}
+
+var (
+	// namedDatabaseDsnPattern matches the DSN variables of named databases, e.g. DB_AUDIT_DSN.
+	namedDatabaseDsnPattern = regexp.MustCompile(`^DB_(.+)_DSN$`)
+
+	// databaseNamePattern matches the allowed database names. Underscores are rejected
+	// because DB_AUDIT_LOG_MIGRATIONS_TABLE would be ambiguous between databases.
+	databaseNamePattern = regexp.MustCompile(`^[A-Z0-9]+$`)
+
+	// reservedDatabaseNames start the DB_* variables of the main database, e.g.
+	// DB_REPLICA_DSNS, so they cannot name a database.
+	reservedDatabaseNames = []string{
+		"CONNECT", "CONNECTION", "DRIVER", "MAX", "MIGRATIONS", "NAME", "POOL", "REPLICA", "SLOW", "STATS",
+	}
+)
+
+// Databases are additional databases by name, next to the main Database.
+// Each database is configured by DB_<NAME>_* environment variables, e.g. DB_AUDIT_DSN
+// adds the "audit" database, see PopulateNamedDatabase. Names only contain letters
+// and digits, and cannot be one of the words starting the main database variables,
+// e.g. REPLICA.
+type Databases map[string]Database
+
+// Populate implements the go-config Config interface for Databases.
+// It adds a database for every DB_<NAME>_DSN environment variable and returns an error
+// for names that are not only letters and digits, e.g. DB_AUDIT_LOG_DSN, or are
+// reserved by the main database, e.g. DB_REPLICA_DSN.
+func (d *Databases) Populate() error {
+	databases := make(Databases)
+	for _, entry := range os.Environ() {
+		key, _, _ := strings.Cut(entry, "=")
+		match := namedDatabaseDsnPattern.FindStringSubmatch(key)
+		if match == nil {
+			continue
+		}
+
+		if !databaseNamePattern.MatchString(match[1]) {
+			return fmt.Errorf(
+				"invalid %s: database names must only contain letters and digits, e.g. DB_AUDITLOG_DSN",
+				key,
+			)
+		}
+		if slices.Contains(reservedDatabaseNames, match[1]) {
+			return fmt.Errorf("invalid %s: %s is reserved by the main database", key, match[1])
+		}
+
+		name := strings.ToLower(match[1])
+		database := Database{}
+		PopulateNamedDatabase(&database, name)
+		databases[name] = database
+	}
+
+	*d = databases
+	return nil
+}
+
+// PopulateNamedDatabase reads the configuration of a named database from environment
+// variables prefixed with DB_<NAME>_, using the defaults of the main database.
+// Migrations default to the migrations/<name> directory and the
+// migrations_executions_<name> table.
+func PopulateNamedDatabase(d *Database, name string) {
+	prefix := "DB_" + strings.ToUpper(name) + "_"
+	appBaseDir, _ := params.GetEnvAsString(AppBaseDirEnvName, "")
+
+	d.populate(prefix, name)
+	d.Migrations.MigrationsDirPath, _ = params.GetEnvAsString(
+		prefix+"MIGRATIONS_DIR_PATH",
+		filepath.Join(appBaseDir, "migrations", name),
+	)
+	d.Migrations.ExecutionsTable, _ = params.GetEnvAsString(
+		prefix+"MIGRATIONS_TABLE",
+		defaultExecutionsTable+"_"+name,
+	)
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDatabasePopulateReadsEverySetting(t *testing.T) {
+	t.Setenv(AppBaseDirEnvName, "/srv/app")
+	t.Setenv("DB_NAME", "app")
+	t.Setenv("DB_DSN", "app:secret@tcp(db)/app")
+	t.Setenv("DB_DRIVER", "postgres")
+	t.Setenv("DB_MAX_OPEN_CONNECTIONS", "20")
+	t.Setenv("DB_REPLICA_DSNS", "replica-1, replica-2")
+
+	database := Database{}
+	if err := database.Populate(); err != nil {
+		t.Fatalf("Populate() error = %v", err)
+	}
+
+	if database.Name != "app" || database.Dsn != "app:secret@tcp(db)/app" || database.Driver != "postgres" {
+		t.Fatalf("database = %+v, want the connection settings", database)
+	}
+	if database.MaxOpenConnections != 20 || database.MaxIdleConnections != 2 ||
+		database.ConnectionMaxLifetime != 3*time.Minute {
+		t.Fatalf("database = %+v, want the pool settings", database)
+	}
+	if len(database.ReplicaDsns) != 2 || database.ReplicaDsns[1] != "replica-2" {
+		t.Fatalf("replica DSNs = %v, want both replicas", database.ReplicaDsns)
+	}
+	if database.Migrations.MigrationsDirPath != filepath.Join("/srv/app", "migrations") ||
+		database.Migrations.ExecutionsTable != "migrations_executions" {
+		t.Fatalf("migrations = %+v, want the main migrations", database.Migrations)
+	}
+}
+
+func TestDatabasesPopulateNamedDatabases(t *testing.T) {
+	t.Setenv(AppBaseDirEnvName, "/srv/app")
+	t.Setenv("DB_AUDIT_DSN", "audit:secret@tcp(audit-db)/audit")
+	t.Setenv("DB_AUDIT_DRIVER", "postgres")
+	t.Setenv("DB_REPORTING_DSN", "reporting.db")
+	t.Setenv("DB_REPORTING_MIGRATIONS_TABLE", "reporting_migrations")
+
+	databases := Databases{}
+	if err := databases.Populate(); err != nil {
+		t.Fatalf("Populate() error = %v", err)
+	}
+
+	audit := databases["audit"]
+	if audit.Name != "audit" || audit.Driver != "postgres" || audit.MaxOpenConnections != 10 {
+		t.Fatalf("audit = %+v, want the audit settings with the main defaults", audit)
+	}
+	if audit.Migrations.MigrationsDirPath != filepath.Join("/srv/app", "migrations", "audit") ||
+		audit.Migrations.ExecutionsTable != "migrations_executions_audit" {
+		t.Fatalf("audit migrations = %+v, want its own directory and table", audit.Migrations)
+	}
+	if table := databases["reporting"].Migrations.ExecutionsTable; table != "reporting_migrations" {
+		t.Fatalf("reporting executions table = %q, want the configured table", table)
+	}
+}
+
+func TestDatabasesPopulateRejectsNamesWithUnderscores(t *testing.T) {
+	t.Setenv("DB_AUDIT_LOG_DSN", "audit-log.db")
+
+	databases := Databases{}
+	err := databases.Populate()
+	if err == nil || !strings.Contains(err.Error(), "DB_AUDIT_LOG_DSN") {
+		t.Fatalf("Populate() error = %v, want the invalid DB_AUDIT_LOG_DSN", err)
+	}
+}
+
+func TestDatabasesPopulateRejectsReservedNames(t *testing.T) {
+	t.Setenv("DB_REPLICA_DSN", "replica.db")
+
+	databases := Databases{}
+	err := databases.Populate()
+	if err == nil || !strings.Contains(err.Error(), "REPLICA is reserved") {
+		t.Fatalf("Populate() error = %v, want the reserved REPLICA name", err)
+	}
+}
+
+func TestNamedDatabasesAreValidated(t *testing.T) {
+	validate, err := NewValidator()
+	if err != nil {
+		t.Fatalf("NewValidator() error = %v", err)
+	}
+	t.Setenv("DB_AUDIT_DSN", "")
+	t.Setenv("DB_AUDIT_MIGRATIONS_DIR_PATH", t.TempDir())
+
+	// The named databases of the application config, see install/app/config.
+	config := struct {
+		Databases Databases `validate:"dive"`
+	}{}
+	if err := config.Databases.Populate(); err != nil {
+		t.Fatalf("Populate() error = %v", err)
+	}
+
+	err = validate.Struct(config)
+	if err == nil || !strings.Contains(err.Error(), "Dsn") {
+		t.Fatalf("Struct() error = %v, want the missing DSN of the audit database", err)
+	}
+
+	t.Setenv("DB_AUDIT_DSN", "audit.db")
+	if err := config.Databases.Populate(); err != nil {
+		t.Fatalf("Populate() error = %v", err)
+	}
+	if err := validate.Struct(config); err != nil {
+		t.Fatalf("Struct() error = %v, want a valid audit database", err)
+	}
+}
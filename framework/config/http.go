@@ -1,22 +1,42 @@
 package config
 
 import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golibry/go-params/params"
 )
 
+const (
+	HttpListenerTCP     = "tcp"
+	HttpListenerUnix    = "unix"
+	HttpListenerSystemd = "systemd"
+)
+
 // HttpServer contains HTTP server configuration settings.
 // It defines how the HTTP server should bind and handle requests.
 type HttpServer struct {
 	// BindAddress specifies the IP address the HTTP server should bind to.
-	// Must be a valid IPv4 address (e.g., "0.0.0.0", "127.0.0.1").
-	BindAddress string `env:"HTTP_BIND_ADDRESS" default:"0.0.0.0" validate:"ipv4"`
+	// Must be a valid IPv4 or IPv6 address (e.g., "0.0.0.0", "127.0.0.1", "::").
+	BindAddress string `env:"HTTP_BIND_ADDRESS" default:"0.0.0.0" validate:"ip"`
 
 	// BindPort specifies the port number the HTTP server should listen to.
 	// Must be a numeric value (e.g., "8080", "3000").
 	BindPort string `env:"HTTP_BIND_PORT" default:"8080" validate:"numeric"`
 
+	// Listeners are the sockets the HTTP server accepts connections on, read from
+	// HTTP_LISTEN as a comma-separated list, e.g. "[::]:8080,unix:/run/app/http.sock".
+	// The server listens on BindAddress and BindPort when it is empty.
+	Listeners []HttpListener `validate:"dive"`
+
+	// UnixSocketMode is the file mode of Unix domain sockets, read from
+	// HTTP_UNIX_SOCKET_MODE in octal notation, e.g. "0660".
+	UnixSocketMode os.FileMode
+
 	// MaxHeaderBytes controls the maximum number of bytes the server will read
 	// parsing the request header's keys and values, including the request line.
 	// Must be between 0 and 64,000 bytes.
@@ -29,6 +49,61 @@ type HttpServer struct {
 	// WriteTimeout specifies the maximum duration before timing out response writes.
 	// A zero or negative value means there will be no timeout.
 	WriteTimeout time.Duration `env:"HTTP_WRITE_TIMEOUT"`
+
+	// TLSCertFile is the PEM certificate chain the server terminates TLS with.
+	// The server listens for plain HTTP when it is empty.
+	TLSCertFile string `env:"HTTP_TLS_CERT_FILE" default:"" validate:"required_with=TLSKeyFile"`
+
+	// TLSKeyFile is the PEM private key of TLSCertFile.
+	TLSKeyFile string `env:"HTTP_TLS_KEY_FILE" default:"" validate:"required_with=TLSCertFile"`
+
+	// TLSClientCAFile is a PEM bundle of the CAs client certificates must be signed by.
+	// Setting it requires a client certificate from every client (mutual TLS).
+	TLSClientCAFile string `env:"HTTP_TLS_CLIENT_CA_FILE" default:"" validate:"excluded_without=TLSCertFile"`
+
+	// TLSReloadInterval is how often the certificate files are checked for changes,
+	// e.g. renewed certificates. A value of 0 reloads them on SIGHUP only.
+	TLSReloadInterval time.Duration `env:"HTTP_TLS_RELOAD_INTERVAL" default:"10s"`
+}
+
+// HttpListener is a socket the HTTP server accepts connections on.
+type HttpListener struct {
+	// Network is "tcp", "unix" or "systemd" for sockets passed by the service manager
+	// through LISTEN_FDS (socket activation).
+	Network string `validate:"required,oneof=tcp unix systemd"`
+
+	// Address is the host and port of TCP listeners, the socket path of Unix listeners
+	// and the optional FileDescriptorName of systemd listeners, all passed sockets when empty.
+	Address string `validate:"required_unless=Network systemd"`
+}
+
+func (l HttpListener) String() string {
+	if l.Network == HttpListenerTCP {
+		return l.Address
+	}
+	if l.Address == "" {
+		return l.Network
+	}
+
+	return l.Network + ":" + l.Address
+}
+
+// ListenersOrDefault returns Listeners or, when it is empty, the TCP listener of
+// BindAddress and BindPort.
+func (h HttpServer) ListenersOrDefault() []HttpListener {
+	if len(h.Listeners) > 0 {
+		return h.Listeners
+	}
+
+	return []HttpListener{{
+		Network: HttpListenerTCP,
+		Address: net.JoinHostPort(h.BindAddress, h.BindPort),
+	}}
+}
+
+// TLSEnabled reports whether the server terminates TLS.
+func (h HttpServer) TLSEnabled() bool {
+	return h.TLSCertFile != ""
 }
 
 // Populate implements the go-config Config interface for HttpServer.
@@ -39,11 +114,67 @@ func (h *HttpServer) Populate() error {
 	maxHeaderBytes, _ := params.GetEnvAsInt("HTTP_MAX_HEADER_BYTES", 1024*16)
 	requestTimeout, _ := params.GetEnvAsDuration("HTTP_REQUEST_TIMEOUT", 30*time.Second)
 	writeTimeout, _ := params.GetEnvAsDuration("HTTP_WRITE_TIMEOUT", requestTimeout)
+	tlsCertFile, _ := params.GetEnvAsString("HTTP_TLS_CERT_FILE", "")
+	tlsKeyFile, _ := params.GetEnvAsString("HTTP_TLS_KEY_FILE", "")
+	tlsClientCAFile, _ := params.GetEnvAsString("HTTP_TLS_CLIENT_CA_FILE", "")
+	tlsReloadInterval, _ := params.GetEnvAsDuration("HTTP_TLS_RELOAD_INTERVAL", 10*time.Second)
+	listen, _ := params.GetEnvAsString("HTTP_LISTEN", "")
+	unixSocketMode, _ := params.GetEnvAsString("HTTP_UNIX_SOCKET_MODE", "0660")
 
 	h.BindAddress = bindAddress
 	h.BindPort = bindPort
 	h.MaxHeaderBytes = maxHeaderBytes
 	h.RequestTimeout = requestTimeout
 	h.WriteTimeout = writeTimeout
+	h.TLSCertFile = tlsCertFile
+	h.TLSKeyFile = tlsKeyFile
+	h.TLSClientCAFile = tlsClientCAFile
+	h.TLSReloadInterval = tlsReloadInterval
+
+	listeners, err := ParseHttpListeners(listen)
+	if err != nil {
+		return fmt.Errorf("invalid HTTP_LISTEN: %w", err)
+	}
+	h.Listeners = listeners
+
+	mode, err := strconv.ParseUint(unixSocketMode, 8, 32)
+	if err != nil || mode > 0o777 {
+		return fmt.Errorf("invalid HTTP_UNIX_SOCKET_MODE %q: must be an octal file mode", unixSocketMode)
+	}
+	h.UnixSocketMode = os.FileMode(mode)
 	return nil
 }
+
+// ParseHttpListeners parses comma-separated listener definitions: "host:port" or
+// "tcp:host:port" for TCP, "unix:path" for Unix domain sockets and "systemd" or
+// "systemd:name" for sockets passed by the service manager.
+func ParseHttpListeners(value string) ([]HttpListener, error) {
+	listeners := make([]HttpListener, 0)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		listener := HttpListener{Network: HttpListenerTCP, Address: entry}
+		if network, address, found := strings.Cut(entry, ":"); found || entry == HttpListenerSystemd {
+			switch network {
+			case HttpListenerTCP, HttpListenerUnix, HttpListenerSystemd:
+				listener = HttpListener{Network: network, Address: address}
+			}
+		}
+
+		if listener.Network == HttpListenerTCP {
+			if _, port, err := net.SplitHostPort(listener.Address); err != nil || port == "" {
+				return nil, fmt.Errorf("listener %q must have the host:port form", entry)
+			}
+		}
+		if listener.Network == HttpListenerUnix && listener.Address == "" {
+			return nil, fmt.Errorf("listener %q must have the unix:path form", entry)
+		}
+
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHttpServerValidatesTLSSettings(t *testing.T) {
+	validate, err := NewValidator()
+	if err != nil {
+		t.Fatalf("NewValidator() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		cert     string
+		key      string
+		clientCA string
+		valid    bool
+	}{
+		{name: "plain HTTP", valid: true},
+		{name: "certificate pair", cert: "cert.pem", key: "key.pem", valid: true},
+		{name: "mutual TLS", cert: "cert.pem", key: "key.pem", clientCA: "ca.pem", valid: true},
+		{name: "certificate without key", cert: "cert.pem"},
+		{name: "key without certificate", key: "key.pem"},
+		{name: "client CA without certificate", clientCA: "ca.pem"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := HttpServer{
+				BindAddress:     "127.0.0.1",
+				BindPort:        "8080",
+				TLSCertFile:     tt.cert,
+				TLSKeyFile:      tt.key,
+				TLSClientCAFile: tt.clientCA,
+			}
+
+			err := validate.Struct(server)
+
+			if (err == nil) != tt.valid {
+				t.Fatalf("Struct() error = %v, want valid = %v", err, tt.valid)
+			}
+			if server.TLSEnabled() != (tt.cert != "") {
+				t.Fatalf("TLSEnabled() = %v, want it only with a certificate", server.TLSEnabled())
+			}
+		})
+	}
+}
+
+func TestParseHttpListeners(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []HttpListener
+		err   string
+	}{
+		{value: "", want: []HttpListener{}},
+		{
+			value: "[::]:8080, tcp:127.0.0.1:9090",
+			want: []HttpListener{
+				{Network: HttpListenerTCP, Address: "[::]:8080"},
+				{Network: HttpListenerTCP, Address: "127.0.0.1:9090"},
+			},
+		},
+		{
+			value: "unix:/run/app/http.sock,systemd,systemd:web",
+			want: []HttpListener{
+				{Network: HttpListenerUnix, Address: "/run/app/http.sock"},
+				{Network: HttpListenerSystemd},
+				{Network: HttpListenerSystemd, Address: "web"},
+			},
+		},
+		{value: "localhost", err: "host:port"},
+		{value: "tcp:8080", err: "host:port"},
+		{value: "unix:", err: "unix:path"},
+	}
+	for _, tt := range tests {
+		listeners, err := ParseHttpListeners(tt.value)
+
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("ParseHttpListeners(%q) error = %v, want %q", tt.value, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(listeners, tt.want) {
+			t.Errorf("ParseHttpListeners(%q) = %v, %v, want %v", tt.value, listeners, err, tt.want)
+		}
+	}
+}
+
+func TestHttpServerPopulateReadsListeners(t *testing.T) {
+	t.Setenv("HTTP_LISTEN", "unix:/run/app/http.sock")
+	t.Setenv("HTTP_UNIX_SOCKET_MODE", "0600")
+
+	server := HttpServer{}
+	if err := server.Populate(); err != nil {
+		t.Fatalf("Populate() error = %v", err)
+	}
+	if server.UnixSocketMode != 0o600 {
+		t.Fatalf("UnixSocketMode = %v, want 0600", server.UnixSocketMode)
+	}
+	want := []HttpListener{{Network: HttpListenerUnix, Address: "/run/app/http.sock"}}
+	if !reflect.DeepEqual(server.ListenersOrDefault(), want) {
+		t.Fatalf("listeners = %v, want %v", server.ListenersOrDefault(), want)
+	}
+
+	t.Setenv("HTTP_LISTEN", "")
+	t.Setenv("HTTP_UNIX_SOCKET_MODE", "0999")
+	if err := server.Populate(); err == nil || !strings.Contains(err.Error(), "HTTP_UNIX_SOCKET_MODE") {
+		t.Fatalf("Populate() error = %v, want the invalid mode reported", err)
+	}
+	want = []HttpListener{{Network: HttpListenerTCP, Address: "0.0.0.0:8080"}}
+	if !reflect.DeepEqual(server.ListenersOrDefault(), want) {
+		t.Fatalf("listeners = %v, want the bind address", server.ListenersOrDefault())
+	}
+}
@@ -5,11 +5,19 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/golibry/go-params/params"
 )
 
+const (
+	LogFormatJSON   = "json"
+	LogFormatText   = "text"
+	LogFormatPretty = "pretty"
+)
+
 type Log struct {
 	// LogLevel defines the minimum log level for the application logger.
 	// Uses slog.Level which supports debug, info, warn, and error levels.
@@ -18,6 +26,65 @@ type Log struct {
 	// LogPath specifies where log output should be directed.
 	// Valid values are: "stdout", "stderr", or a file path.
 	LogPath string `env:"APP_LOG_PATH" default:"stdout" validate:"required,logpath"`
+
+	// LogFormat specifies the format of the LogPath output.
+	// Valid values are: "json", "text" (logfmt) and "pretty" (colored, human-readable).
+	LogFormat string `env:"APP_LOG_FORMAT" default:"json" validate:"required,oneof=json text pretty"`
+
+	// LogSinks lists additional log outputs, each with its own path, format and minimum
+	// level. Records are written to LogPath and to every sink that accepts their level.
+	// It is read from APP_LOG_SINKS as comma-separated "format:level:path" entries,
+	// e.g. "json:error:/var/log/app/errors.log".
+	LogSinks []LogSink `validate:"dive"`
+
+	// LogMaxSize is the size in megabytes after which the log file is rotated.
+	// A value of 0 disables size-based rotation. Only used when LogPath is a file.
+	LogMaxSize int `env:"APP_LOG_MAX_SIZE" default:"0" validate:"gte=0"`
+
+	// LogMaxAge is the age after which the log file is rotated, e.g. "24h".
+	// A value of 0 disables age-based rotation. Only used when LogPath is a file.
+	LogMaxAge time.Duration `env:"APP_LOG_MAX_AGE" default:"0"`
+
+	// LogMaxBackups is the number of rotated log files to keep.
+	// A value of 0 keeps all rotated files.
+	LogMaxBackups int `env:"APP_LOG_MAX_BACKUPS" default:"0" validate:"gte=0"`
+
+	// LogCompress enables gzip compression of rotated log files.
+	LogCompress bool `env:"APP_LOG_COMPRESS" default:"false"`
+
+	// LogReopenOnSighup reopens the log file when the process receives SIGHUP,
+	// so external tools can move the file away without copytruncate.
+	LogReopenOnSighup bool `env:"APP_LOG_REOPEN_ON_SIGHUP" default:"true"`
+
+	// LogDebugSignalDuration is how long SIGUSR1 switches the log level to debug
+	// before reverting, e.g. "10m". A value of 0 disables the signal toggle.
+	LogDebugSignalDuration time.Duration `env:"APP_LOG_DEBUG_SIGNAL_DURATION" default:"0"`
+
+	// LogAdminToken is the bearer token protecting the log level and db stats admin
+	// endpoints. The endpoints are not registered when it is empty.
+	LogAdminToken string `env:"APP_LOG_ADMIN_TOKEN" default:""`
+
+	// LogRedact masks passwords, tokens, DSN credentials and card numbers in log records.
+	// It is nil when APP_LOG_REDACT is not set, leaving the default to the environment:
+	// app.NewContainerFromConfig enables it in the "prod" and "stg" environments.
+	LogRedact *bool `env:"APP_LOG_REDACT"`
+
+	// LogRedactKeys lists additional attribute key patterns to redact, matched as
+	// case-insensitive substrings. It is read from APP_LOG_REDACT_KEYS as a
+	// comma-separated list, e.g. "ssn,iban".
+	LogRedactKeys []string
+}
+
+// LogSink is an additional log output.
+type LogSink struct {
+	// Path is "stdout", "stderr" or a file path.
+	Path string `validate:"required,logpath"`
+
+	// Format is one of "json", "text" or "pretty".
+	Format string `validate:"required,oneof=json text pretty"`
+
+	// Level is the minimum level written to the sink.
+	Level slog.Level
 }
 
 // Populate implements the go-config Config interface for the top-level Log.
@@ -25,12 +92,72 @@ type Log struct {
 func (c *Log) Populate() error {
 	logLevel, _ := params.GetEnvAsString("APP_LOG_LEVEL", "warn")
 	logPath, _ := params.GetEnvAsString("APP_LOG_PATH", "stdout")
+	logFormat, _ := params.GetEnvAsString("APP_LOG_FORMAT", LogFormatJSON)
+	logSinks, _ := params.GetEnvAsString("APP_LOG_SINKS", "")
+	logMaxSize, _ := params.GetEnvAsInt("APP_LOG_MAX_SIZE", 0)
+	logMaxAge, _ := params.GetEnvAsDuration("APP_LOG_MAX_AGE", 0)
+	logMaxBackups, _ := params.GetEnvAsInt("APP_LOG_MAX_BACKUPS", 0)
+	logCompress, _ := params.GetEnvAsBool("APP_LOG_COMPRESS", false)
+	logReopenOnSighup, _ := params.GetEnvAsBool("APP_LOG_REOPEN_ON_SIGHUP", true)
+	logDebugSignalDuration, _ := params.GetEnvAsDuration("APP_LOG_DEBUG_SIGNAL_DURATION", 0)
+	logAdminToken, _ := params.GetEnvAsString("APP_LOG_ADMIN_TOKEN", "")
+	logRedactKeys, _ := params.GetEnvAsString("APP_LOG_REDACT_KEYS", "")
 
 	c.LogLevel = parseLogLevel(logLevel)
 	c.LogPath = logPath
+	c.LogFormat = logFormat
+	c.LogMaxSize = logMaxSize
+	c.LogMaxAge = logMaxAge
+	c.LogMaxBackups = logMaxBackups
+	c.LogCompress = logCompress
+	c.LogReopenOnSighup = logReopenOnSighup
+	c.LogDebugSignalDuration = logDebugSignalDuration
+	c.LogAdminToken = logAdminToken
+	c.LogRedact = nil
+	if _, ok := os.LookupEnv("APP_LOG_REDACT"); ok {
+		logRedact, _ := params.GetEnvAsBool("APP_LOG_REDACT", false)
+		c.LogRedact = &logRedact
+	}
+	c.LogRedactKeys = make([]string, 0)
+	for _, key := range strings.Split(logRedactKeys, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			c.LogRedactKeys = append(c.LogRedactKeys, key)
+		}
+	}
+
+	sinks, err := ParseLogSinks(logSinks)
+	if err != nil {
+		return fmt.Errorf("invalid APP_LOG_SINKS: %w", err)
+	}
+	c.LogSinks = sinks
 	return nil
 }
 
+// ParseLogSinks parses comma-separated "format:level:path" sink definitions.
+// The path comes last, so it may contain colons.
+func ParseLogSinks(value string) ([]LogSink, error) {
+	sinks := make([]LogSink, 0)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[2] == "" {
+			return nil, fmt.Errorf("log sink %q must have the format:level:path form", entry)
+		}
+
+		sinks = append(sinks, LogSink{
+			Format: parts[0],
+			Level:  parseLogLevel(parts[1]),
+			Path:   parts[2],
+		})
+	}
+
+	return sinks, nil
+}
+
 // RegisterLogValidator registers custom log validation functions with the validator instance.
 func RegisterLogValidator(validate *validator.Validate) error {
 	// Register log path validation
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestLogPopulateLeavesTheRedactDefaultToTheEnvironment(t *testing.T) {
+	t.Setenv("APP_ENV", "prod")
+	t.Setenv("APP_LOG_REDACT", "")
+	_ = os.Unsetenv("APP_LOG_REDACT")
+
+	var log Log
+	if err := log.Populate(); err != nil {
+		t.Fatalf("Populate() error = %v", err)
+	}
+	if log.LogRedact != nil {
+		t.Fatalf("LogRedact = %v, want nil without APP_LOG_REDACT", *log.LogRedact)
+	}
+
+	t.Setenv("APP_LOG_REDACT", "false")
+	if err := log.Populate(); err != nil {
+		t.Fatalf("Populate() error = %v", err)
+	}
+	if log.LogRedact == nil || *log.LogRedact {
+		t.Fatalf("LogRedact = %v, want false from APP_LOG_REDACT", log.LogRedact)
+	}
+}
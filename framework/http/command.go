@@ -4,6 +4,7 @@ import (
 	"io"
 
 	"github.com/golibry/go-cli-command/cli"
+	"github.com/golibry/go-web-skeleton/framework/app"
 )
 
 type Command struct {
@@ -25,7 +26,8 @@ func (c *Command) Description() string {
 	return "Starts the HTTP server"
 }
 
+// Exec runs the server until app.BaseContext is canceled, on shutdown signals when the
+// command is run by the CLI bootstrap.
 func (c *Command) Exec(_ io.Writer) error {
-	Start(c.Options)
-	return nil
+	return Run(app.BaseContext(), c.Options)
 }
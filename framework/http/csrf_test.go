@@ -0,0 +1,56 @@
+package http
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	nethttp "net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golibry/go-web-skeleton/framework/app"
+)
+
+func TestAdminEndpointsSkipCSRFThroughTheMiddlewareChain(t *testing.T) {
+	service, err := app.NewLoggerService(
+		filepath.Join(t.TempDir(), "app.log"),
+		slog.LevelInfo,
+		app.LoggerOptions{},
+	)
+	if err != nil {
+		t.Fatalf("NewLoggerService() error = %v", err)
+	}
+	defer func() { _ = service.Close() }()
+
+	router := nethttp.NewServeMux()
+	registerAdminRoutes(router, Options{
+		LogLevelAdmin: &LogLevelAdminOptions{Token: "secret", LoggerService: service},
+	})
+	handler := buildGlobalMiddlewareChain(
+		router,
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		context.Background(),
+		MiddlewareOptions{},
+		0,
+	)
+
+	request := httptest.NewRequest(
+		nethttp.MethodPut,
+		DefaultLogLevelAdminPath,
+		strings.NewReader(`{"level":"debug"}`),
+	)
+	request.Header.Set("Authorization", "Bearer secret")
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+
+	handler.ServeHTTP(recorder, request)
+
+	if recorder.Code != nethttp.StatusOK {
+		t.Fatalf("PUT %s status = %d, want 200: %s", DefaultLogLevelAdminPath, recorder.Code, recorder.Body)
+	}
+	if service.Level() != slog.LevelDebug {
+		t.Fatalf("level = %v, want debug", service.Level())
+	}
+}
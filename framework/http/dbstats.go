@@ -0,0 +1,62 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	nethttp "net/http"
+	"time"
+
+	"github.com/golibry/go-web-skeleton/framework/app"
+)
+
+// DefaultDBStatsAdminPath is the route of the db stats admin endpoint.
+const DefaultDBStatsAdminPath = "/admin/db-stats"
+
+// DBStatsAdminOptions configures the db stats admin endpoint, read by the db:stats
+// command with its -url flag.
+type DBStatsAdminOptions struct {
+	// Path defaults to DefaultDBStatsAdminPath.
+	Path string
+
+	// Token is the bearer token required by the endpoint. Requests are refused when
+	// it is empty.
+	Token string
+
+	// Databases are reported, usually the DBServices of the container.
+	Databases []*app.SQLDBService
+
+	// HealthCheckTimeout bounds the database pings, 5 seconds when zero.
+	HealthCheckTimeout time.Duration
+}
+
+// NewDBStatsHandler serves the app.DBStatsReport of the databases of options on GET.
+// Every request must carry the token as an "Authorization: Bearer" header.
+func NewDBStatsHandler(options DBStatsAdminOptions) nethttp.Handler {
+	timeout := options.HealthCheckTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	return nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if !validBearerToken(r, options.Token) {
+			writeUnauthorized(w)
+			return
+		}
+		if r.Method != nethttp.MethodGet {
+			w.Header().Set("Allow", "GET")
+			nethttp.Error(
+				w,
+				nethttp.StatusText(nethttp.StatusMethodNotAllowed),
+				nethttp.StatusMethodNotAllowed,
+			)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(app.NewDBStatsReport(ctx, options.Databases))
+	})
+}
@@ -0,0 +1,154 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/golibry/go-web-skeleton/framework/config"
+)
+
+// systemdListenFdsStart is the first file descriptor passed by socket activation.
+const systemdListenFdsStart = 3
+
+var (
+	systemdFilesOnce sync.Once
+	systemdFiles     []*os.File
+	systemdFilesErr  error
+)
+
+// Listen opens the listeners of serverConfig: TCP sockets, Unix domain sockets and
+// sockets passed by the service manager. The listeners opened so far are closed when
+// one of them fails.
+func Listen(serverConfig config.HttpServer) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0)
+	closeAll := func() {
+		for _, listener := range listeners {
+			_ = listener.Close()
+		}
+	}
+
+	for _, definition := range serverConfig.ListenersOrDefault() {
+		var opened []net.Listener
+		var err error
+		switch definition.Network {
+		case config.HttpListenerUnix:
+			var listener net.Listener
+			listener, err = listenUnix(definition.Address, serverConfig.UnixSocketMode)
+			opened = []net.Listener{listener}
+		case config.HttpListenerSystemd:
+			opened, err = listenSystemd(definition.Address)
+		default:
+			var listener net.Listener
+			listener, err = net.Listen("tcp", definition.Address)
+			opened = []net.Listener{listener}
+		}
+		if err != nil {
+			closeAll()
+			return nil, fmt.Errorf("failed to listen on %s: %w", definition, err)
+		}
+
+		listeners = append(listeners, opened...)
+	}
+
+	return listeners, nil
+}
+
+// listenUnix listens on the Unix domain socket at path, replacing the stale socket
+// file of a previous process. The socket file is removed when the listener closes.
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if info, err := os.Stat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("%s is in use by another process", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to set socket file mode: %w", err)
+	}
+
+	return listener, nil
+}
+
+// listenSystemd returns the listeners of the sockets passed by the service manager,
+// only those with the FileDescriptorName name when it is not empty.
+func listenSystemd(name string) ([]net.Listener, error) {
+	files, err := systemdListenFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	listeners := make([]net.Listener, 0)
+	for _, file := range files {
+		if name != "" && file.Name() != name {
+			continue
+		}
+
+		listener, err := net.FileListener(file)
+		if err != nil {
+			for _, opened := range listeners {
+				_ = opened.Close()
+			}
+			return nil, fmt.Errorf("failed to use passed socket %s: %w", file.Name(), err)
+		}
+		listeners = append(listeners, listener)
+	}
+
+	if len(listeners) == 0 {
+		if name != "" {
+			return nil, fmt.Errorf("no socket named %q was passed in LISTEN_FDNAMES", name)
+		}
+		return nil, errors.New("no sockets were passed in LISTEN_FDS")
+	}
+
+	return listeners, nil
+}
+
+// systemdListenFiles returns the sockets passed through the LISTEN_PID, LISTEN_FDS and
+// LISTEN_FDNAMES variables of the socket activation protocol. The variables are unset,
+// so child processes do not inherit them, and the files are read once.
+func systemdListenFiles() ([]*os.File, error) {
+	systemdFilesOnce.Do(func() {
+		pid, fds, names := os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS"), os.Getenv("LISTEN_FDNAMES")
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+
+		if pid != strconv.Itoa(os.Getpid()) {
+			systemdFilesErr = errors.New("LISTEN_PID does not match the process, no sockets were passed")
+			return
+		}
+		count, err := strconv.Atoi(fds)
+		if err != nil || count < 1 {
+			systemdFilesErr = fmt.Errorf("invalid LISTEN_FDS %q", fds)
+			return
+		}
+
+		fdNames := strings.Split(names, ":")
+		for i := range count {
+			name := "LISTEN_FD_" + strconv.Itoa(systemdListenFdsStart+i)
+			if i < len(fdNames) && fdNames[i] != "" {
+				name = fdNames[i]
+			}
+			systemdFiles = append(systemdFiles, os.NewFile(uintptr(systemdListenFdsStart+i), name))
+		}
+	})
+
+	return systemdFiles, systemdFilesErr
+}
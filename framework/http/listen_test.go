@@ -0,0 +1,206 @@
+package http
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/golibry/go-web-skeleton/framework/config"
+)
+
+// socketDir returns a temporary directory with a path short enough for Unix sockets.
+func socketDir(t *testing.T) string {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "listen")
+	if err != nil {
+		t.Fatalf("MkdirTemp() error = %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	return dir
+}
+
+func TestListenOpensTCPAndUnixSockets(t *testing.T) {
+	path := filepath.Join(socketDir(t), "http.sock")
+
+	listeners, err := Listen(config.HttpServer{
+		Listeners: []config.HttpListener{
+			{Network: config.HttpListenerTCP, Address: "127.0.0.1:0"},
+			{Network: config.HttpListenerUnix, Address: path},
+		},
+		UnixSocketMode: 0o600,
+	})
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer func() {
+		for _, listener := range listeners {
+			_ = listener.Close()
+		}
+	}()
+
+	if len(listeners) != 2 || listeners[0].Addr().Network() != "tcp" || listeners[1].Addr().String() != path {
+		t.Fatalf("listeners = %v, want the TCP and Unix listeners", listeners)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if info.Mode()&os.ModeSocket == 0 || info.Mode().Perm() != 0o600 {
+		t.Fatalf("socket mode = %v, want a socket with 0600", info.Mode())
+	}
+}
+
+func TestListenClosesOpenedListenersOnFailure(t *testing.T) {
+	dir := socketDir(t)
+	path := filepath.Join(dir, "http.sock")
+
+	_, err := Listen(config.HttpServer{
+		Listeners: []config.HttpListener{
+			{Network: config.HttpListenerUnix, Address: path},
+			{Network: config.HttpListenerUnix, Address: filepath.Join(dir, "missing", "http.sock")},
+		},
+		UnixSocketMode: 0o660,
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "failed to listen on unix:") {
+		t.Fatalf("Listen() error = %v, want the failed listener reported", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("Stat() error = %v, want the first socket closed and removed", err)
+	}
+}
+
+func TestListenUnixReplacesStaleSockets(t *testing.T) {
+	dir := socketDir(t)
+
+	stale := filepath.Join(dir, "stale.sock")
+	previous, err := net.Listen("unix", stale)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	previous.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = previous.Close()
+
+	inUse := filepath.Join(dir, "in-use.sock")
+	running, err := net.Listen("unix", inUse)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer func() { _ = running.Close() }()
+
+	regular := filepath.Join(dir, "regular.sock")
+	if err := os.WriteFile(regular, nil, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	tests := []struct {
+		path string
+		err  string
+	}{
+		{path: stale},
+		{path: inUse, err: "in use by another process"},
+		{path: regular, err: "is not a socket"},
+	}
+	for _, tt := range tests {
+		listener, err := listenUnix(tt.path, 0o660)
+
+		if tt.err == "" {
+			if err != nil {
+				t.Fatalf("listenUnix(%s) error = %v", tt.path, err)
+			}
+			_ = listener.Close()
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Fatalf("listenUnix(%s) error = %v, want %q", tt.path, err, tt.err)
+		}
+	}
+}
+
+// systemdChildEnv selects the socket activation scenario run by the child process of
+// TestListenUsesSystemdSockets.
+const systemdChildEnv = "LISTEN_TEST_CHILD"
+
+func TestListenUsesSystemdSockets(t *testing.T) {
+	switch os.Getenv(systemdChildEnv) {
+	case "":
+	case "activated":
+		testSystemdActivatedChild(t)
+		return
+	case "other-process":
+		testSystemdOtherProcessChild(t)
+		return
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+	file, err := listener.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatalf("File() error = %v", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	for _, scenario := range []string{"activated", "other-process"} {
+		// The passed socket becomes descriptor 3 of the child, like systemd passes it.
+		child := exec.Command(os.Args[0], "-test.run=^TestListenUsesSystemdSockets$", "-test.v")
+		child.Env = append(
+			os.Environ(),
+			systemdChildEnv+"="+scenario,
+			"LISTEN_FDS=1",
+			"LISTEN_FDNAMES=web",
+			"LISTEN_TEST_ADDRESS="+listener.Addr().String(),
+		)
+		child.ExtraFiles = []*os.File{file}
+
+		output, err := child.CombinedOutput()
+		if err != nil || !strings.Contains(string(output), "PASS") {
+			t.Fatalf("%s child error = %v, output:\n%s", scenario, err, output)
+		}
+	}
+}
+
+func testSystemdActivatedChild(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+
+	listeners, err := Listen(config.HttpServer{
+		Listeners: []config.HttpListener{{Network: config.HttpListenerSystemd, Address: "web"}},
+	})
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	if len(listeners) != 1 || listeners[0].Addr().String() != os.Getenv("LISTEN_TEST_ADDRESS") {
+		t.Fatalf("listeners = %v, want the passed socket", listeners)
+	}
+	for _, name := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+		if _, ok := os.LookupEnv(name); ok {
+			t.Fatalf("%s is set, want it unset for child processes", name)
+		}
+	}
+
+	_, err = Listen(config.HttpServer{
+		Listeners: []config.HttpListener{{Network: config.HttpListenerSystemd, Address: "admin"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), `no socket named "admin"`) {
+		t.Fatalf("Listen() error = %v, want the missing name reported", err)
+	}
+}
+
+func testSystemdOtherProcessChild(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getppid()))
+
+	_, err := Listen(config.HttpServer{
+		Listeners: []config.HttpListener{{Network: config.HttpListenerSystemd}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "LISTEN_PID does not match") {
+		t.Fatalf("Listen() error = %v, want the sockets of another process ignored", err)
+	}
+}
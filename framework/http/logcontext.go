@@ -0,0 +1,68 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	nethttp "net/http"
+	"strings"
+
+	"github.com/golibry/go-web-skeleton/framework/app"
+)
+
+// RequestIDHeader carries the request ID between services.
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 128
+
+// NewLogContextMiddleware adds the request ID and the W3C traceparent trace and span
+// IDs to the log attributes of the request context. An incoming request ID is kept,
+// otherwise one is generated, and it is echoed in the response headers.
+//
+// Handlers add the tenant and user with app.WithTenant and app.WithUserID.
+func NewLogContextMiddleware(handler nethttp.Handler) nethttp.Handler {
+	return nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		ctx := app.WithRequestID(r.Context(), requestID)
+		if traceID, spanID, ok := parseTraceparent(r.Header.Get("traceparent")); ok {
+			ctx = app.WithTrace(ctx, traceID, spanID)
+		}
+
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+// parseTraceparent reads a "version-traceid-spanid-flags" W3C traceparent header.
+func parseTraceparent(header string) (string, string, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", "", false
+	}
+	if !isLowerHex(parts[1]) || !isLowerHex(parts[2]) ||
+		strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return "", "", false
+	}
+
+	return parts[1], parts[2], true
+}
+
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,139 @@
+package http
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	nethttp "net/http"
+	"strings"
+	"time"
+
+	"github.com/golibry/go-web-skeleton/framework/app"
+)
+
+// DefaultLogLevelAdminPath is the route of the log level admin endpoint.
+const DefaultLogLevelAdminPath = "/admin/log-level"
+
+// LogLevelAdminOptions configures the log level admin endpoint.
+type LogLevelAdminOptions struct {
+	// Path defaults to DefaultLogLevelAdminPath.
+	Path string
+
+	// Token is the bearer token required by the endpoint. Requests are refused when
+	// it is empty.
+	Token string
+
+	LoggerService *app.LoggerService
+}
+
+type logLevelResponse struct {
+	Level      string            `json:"level"`
+	Components map[string]string `json:"components"`
+}
+
+// logLevelRequest changes the base level, or the level of Component when set.
+// Duration reverts a base level change after it elapses, e.g. "10m". Reset removes
+// the override of Component.
+type logLevelRequest struct {
+	Level     string `json:"level"`
+	Component string `json:"component"`
+	Duration  string `json:"duration"`
+	Reset     bool   `json:"reset"`
+}
+
+// NewLogLevelHandler serves the current log levels on GET and changes them on PUT or
+// POST. Every request must carry the token as an "Authorization: Bearer" header.
+func NewLogLevelHandler(service *app.LoggerService, token string) nethttp.Handler {
+	return nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if !validBearerToken(r, token) {
+			writeUnauthorized(w)
+			return
+		}
+
+		switch r.Method {
+		case nethttp.MethodGet:
+		case nethttp.MethodPut, nethttp.MethodPost:
+			if err := applyLogLevelRequest(service, r); err != nil {
+				nethttp.Error(w, err.Error(), nethttp.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			nethttp.Error(
+				w,
+				nethttp.StatusText(nethttp.StatusMethodNotAllowed),
+				nethttp.StatusMethodNotAllowed,
+			)
+			return
+		}
+
+		response := logLevelResponse{
+			Level:      service.Level().String(),
+			Components: make(map[string]string),
+		}
+		for component, level := range service.ComponentLevels() {
+			response.Components[component] = level.String()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	})
+}
+
+func applyLogLevelRequest(service *app.LoggerService, r *nethttp.Request) error {
+	var request logLevelRequest
+	if err := json.NewDecoder(nethttp.MaxBytesReader(nil, r.Body, 4096)).Decode(&request); err != nil {
+		return err
+	}
+
+	if request.Reset {
+		if request.Component == "" {
+			return errors.New("reset requires a component")
+		}
+		service.ResetComponentLevel(request.Component)
+		return nil
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(request.Level)); err != nil {
+		return err
+	}
+
+	if request.Component != "" {
+		service.SetComponentLevel(request.Component, level)
+		return nil
+	}
+
+	if request.Duration == "" {
+		service.SetLevel(level)
+		return nil
+	}
+
+	duration, err := time.ParseDuration(request.Duration)
+	if err != nil {
+		return err
+	}
+	service.SetLevelFor(level, duration)
+
+	return nil
+}
+
+// writeUnauthorized refuses a request without a valid bearer token, asking for one.
+func writeUnauthorized(w nethttp.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	nethttp.Error(w, nethttp.StatusText(nethttp.StatusUnauthorized), nethttp.StatusUnauthorized)
+}
+
+func validBearerToken(r *nethttp.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+
+	provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
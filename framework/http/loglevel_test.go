@@ -0,0 +1,55 @@
+package http
+
+import (
+	"log/slog"
+	nethttp "net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golibry/go-web-skeleton/framework/app"
+)
+
+func TestLogLevelHandlerRejectsInvalidRequests(t *testing.T) {
+	service, err := app.NewLoggerService(
+		filepath.Join(t.TempDir(), "app.log"),
+		slog.LevelInfo,
+		app.LoggerOptions{},
+	)
+	if err != nil {
+		t.Fatalf("NewLoggerService() error = %v", err)
+	}
+	defer func() { _ = service.Close() }()
+	handler := NewLogLevelHandler(service, "secret")
+
+	tests := []struct {
+		name          string
+		authorization string
+		body          string
+		status        int
+	}{
+		{name: "missing token", body: `{"level":"debug"}`, status: nethttp.StatusUnauthorized},
+		{name: "wrong token", authorization: "Bearer other", body: `{"level":"debug"}`, status: nethttp.StatusUnauthorized},
+		{name: "reset without component", authorization: "Bearer secret", body: `{"reset":true}`, status: nethttp.StatusBadRequest},
+		{name: "reset component", authorization: "Bearer secret", body: `{"reset":true,"component":"db"}`, status: nethttp.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(nethttp.MethodPut, DefaultLogLevelAdminPath, strings.NewReader(tt.body))
+			if tt.authorization != "" {
+				request.Header.Set("Authorization", tt.authorization)
+			}
+			recorder := httptest.NewRecorder()
+
+			handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.status, recorder.Body)
+			}
+			if tt.status == nethttp.StatusUnauthorized && recorder.Header().Get("WWW-Authenticate") != "Bearer" {
+				t.Fatalf("WWW-Authenticate = %q, want Bearer", recorder.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"context"
+	nethttp "net/http"
+)
+
+// RouteMarks are the middleware skipped by the requests of a route, set with
+// StreamingHandler and CSRFExemptHandler.
+type RouteMarks struct {
+	// Streaming skips the request timeout.
+	Streaming bool
+
+	// CSRFExempt skips the CSRF protection.
+	CSRFExempt bool
+}
+
+// RouteMarker is implemented by the handlers marked with StreamingHandler or
+// CSRFExemptHandler. Handlers wrapping a marked handler implement it too, returning
+// the marks of the wrapped handler, so the marks survive the wrapper.
+type RouteMarker interface {
+	RouteMarks() RouteMarks
+}
+
+// markedHandler is a handler with route marks. Marking a marked handler again adds to
+// its marks, so StreamingHandler(CSRFExemptHandler(h)) has both.
+type markedHandler struct {
+	nethttp.Handler
+	marks RouteMarks
+}
+
+func (h markedHandler) RouteMarks() RouteMarks {
+	return h.marks
+}
+
+func markHandler(handler nethttp.Handler, mark func(marks *RouteMarks)) nethttp.Handler {
+	marks := routeMarksOf(handler)
+	mark(&marks)
+
+	return markedHandler{Handler: handler, marks: marks}
+}
+
+func routeMarksOf(handler nethttp.Handler) RouteMarks {
+	if marker, ok := handler.(RouteMarker); ok {
+		return marker.RouteMarks()
+	}
+
+	return RouteMarks{}
+}
+
+type routeMarksKey struct{}
+
+// newRouteLookup matches requests against router once and passes the marks of their
+// route to the bypasses of handler on the request context. Requests under one of
+// streamingPaths are marked streaming. It must wrap the bypasses inside the path
+// normalizer, so the route is the one router serves.
+func newRouteLookup(
+	handler nethttp.Handler,
+	router *nethttp.ServeMux,
+	streamingPaths []string,
+) nethttp.Handler {
+	return nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		routed, _ := router.Handler(r)
+		marks := routeMarksOf(routed)
+		for _, path := range streamingPaths {
+			if hasPathPrefix(r.URL.Path, path) {
+				marks.Streaming = true
+			}
+		}
+
+		handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), routeMarksKey{}, marks)))
+	})
+}
+
+// routeMarksFromContext returns the marks found by newRouteLookup, none without it.
+func routeMarksFromContext(ctx context.Context) RouteMarks {
+	marks, _ := ctx.Value(routeMarksKey{}).(RouteMarks)
+	return marks
+}
@@ -0,0 +1,30 @@
+package http
+
+import (
+	"context"
+	"log/slog"
+	nethttp "net/http"
+
+	"github.com/golibry/go-web-skeleton/framework/app"
+)
+
+// NewRequestScopeMiddleware opens a container scope for every request, exposes it
+// through the request context and closes it once the handler returns.
+//
+// Handlers read the scope with app.ScopeFromContext.
+func NewRequestScopeMiddleware(
+	handler nethttp.Handler,
+	opener app.ScopeOpener,
+	logger *slog.Logger,
+) nethttp.Handler {
+	return nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		ctx, closeScope := opener.OpenScope(r.Context())
+		defer func() {
+			if err := closeScope(context.WithoutCancel(ctx)); err != nil {
+				logger.ErrorContext(ctx, "Failed to close request scope", "error", err)
+			}
+		}()
+
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
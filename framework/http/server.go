@@ -7,12 +7,10 @@ import (
 	"log/slog"
 	"net"
 	nethttp "net/http"
-	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/golibry/go-http/http/router/middleware"
+	"github.com/golibry/go-web-skeleton/framework/app"
 	"github.com/golibry/go-web-skeleton/framework/config"
 )
 
@@ -22,6 +20,16 @@ type Options struct {
 	RegisterRoutes func(router *nethttp.ServeMux)
 	Middleware     MiddlewareOptions
 
+	// Lifecycle runs the server next to the app components, e.g. the container lifecycle.
+	// A standalone lifecycle is used when it is nil.
+	Lifecycle *app.Lifecycle
+
+	// LogLevelAdmin registers the log level admin endpoint when set.
+	LogLevelAdmin *LogLevelAdminOptions
+
+	// DBStatsAdmin registers the db stats admin endpoint when set.
+	DBStatsAdmin *DBStatsAdminOptions
+
 	// BuildGlobalMiddlewareChain wraps the router with middleware components, handlers
 	BuildGlobalMiddlewareChain func(
 		router *nethttp.ServeMux,
@@ -39,31 +47,52 @@ type MiddlewareOptions struct {
 	EnableRequestTimeout  bool
 	RequestTimeout        *middleware.TimeoutOptions
 	DisableRecoverer      bool
+
+	// StreamingPaths are path prefixes of streamed responses, e.g. NDJSON exports or
+	// Server-Sent Events, which skip the request timeout like routes registered with
+	// StreamingHandler do.
+	StreamingPaths []string
+
+	// DisableLogContext skips adding the request ID and trace IDs to the log
+	// attributes of request contexts.
+	DisableLogContext bool
+
+	// RequestScope opens a container scope per request when set, usually the app container.
+	RequestScope app.ScopeOpener
 }
 
+// NewServer returns the HTTP server of options, with its context and the function
+// canceling it. It panics when the options are invalid or the TLS certificate cannot
+// be loaded, see NewServerE.
 func NewServer(options Options) (*nethttp.Server, context.Context, context.CancelFunc) {
-	if options.RegisterRoutes == nil {
-		panic(
-			fmt.Errorf(
-				"failed to start web server: %s",
-				"register routes function is required",
-			),
-		)
+	server, serverCtx, serverStopCtx, err := NewServerE(options)
+	if err != nil {
+		panic(fmt.Errorf("failed to start web server: %w", err))
 	}
 
-	if options.Logger == nil {
-		panic(
-			fmt.Errorf(
-				"failed to start web server: %s",
-				"logger is required",
-			),
-		)
+	return server, serverCtx, serverStopCtx
+}
+
+// NewServerE is NewServer returning its setup errors. When TLS is configured, the
+// server certificate is reloaded until the server context is canceled.
+func NewServerE(options Options) (*nethttp.Server, context.Context, context.CancelFunc, error) {
+	if err := validateOptions(options); err != nil {
+		return nil, nil, nil, err
 	}
 
 	router := nethttp.NewServeMux()
 	options.RegisterRoutes(router)
+	registerAdminRoutes(router, options)
 
+	// Addr is the first TCP listener, used by ListenAndServe. AppendToLifecycle serves
+	// every listener of the configuration.
 	addr := net.JoinHostPort(options.ServerConfig.BindAddress, options.ServerConfig.BindPort)
+	for _, listener := range options.ServerConfig.ListenersOrDefault() {
+		if listener.Network == config.HttpListenerTCP {
+			addr = listener.Address
+			break
+		}
+	}
 	serverCtx, serverStopCtx := context.WithCancel(context.Background())
 
 	var handler nethttp.Handler
@@ -79,7 +108,7 @@ func NewServer(options Options) (*nethttp.Server, context.Context, context.Cance
 		)
 	}
 
-	return &nethttp.Server{
+	server := &nethttp.Server{
 		Addr:              addr,
 		Handler:           handler,
 		MaxHeaderBytes:    options.ServerConfig.MaxHeaderBytes,
@@ -87,30 +116,167 @@ func NewServer(options Options) (*nethttp.Server, context.Context, context.Cance
 		IdleTimeout:       options.ServerConfig.RequestTimeout,
 		ReadTimeout:       options.ServerConfig.RequestTimeout,
 		WriteTimeout:      options.ServerConfig.WriteTimeout,
-	}, serverCtx, serverStopCtx
+	}
+
+	if options.ServerConfig.TLSEnabled() {
+		reloader, err := NewCertificateReloader(
+			options.ServerConfig.TLSCertFile,
+			options.ServerConfig.TLSKeyFile,
+			options.Logger,
+		)
+		if err != nil {
+			serverStopCtx()
+			return nil, nil, nil, err
+		}
+		server.TLSConfig, err = NewTLSConfig(options.ServerConfig, reloader)
+		if err != nil {
+			serverStopCtx()
+			return nil, nil, nil, err
+		}
+		go reloader.Watch(serverCtx, options.ServerConfig.TLSReloadInterval)
+	}
+
+	return server, serverCtx, serverStopCtx, nil
+}
+
+// registerAdminRoutes registers the configured admin endpoints. They authenticate
+// requests by bearer token, so they are exempt from the CSRF protection.
+func registerAdminRoutes(router *nethttp.ServeMux, options Options) {
+	if options.LogLevelAdmin != nil {
+		path := options.LogLevelAdmin.Path
+		if path == "" {
+			path = DefaultLogLevelAdminPath
+		}
+		router.Handle(path, CSRFExemptHandler(
+			NewLogLevelHandler(options.LogLevelAdmin.LoggerService, options.LogLevelAdmin.Token),
+		))
+	}
+	if options.DBStatsAdmin != nil {
+		path := options.DBStatsAdmin.Path
+		if path == "" {
+			path = DefaultDBStatsAdminPath
+		}
+		router.Handle(path, CSRFExemptHandler(NewDBStatsHandler(*options.DBStatsAdmin)))
+	}
+}
+
+func validateOptions(options Options) error {
+	if options.RegisterRoutes == nil {
+		return errors.New("register routes function is required")
+	}
+	if options.Logger == nil {
+		return errors.New("logger is required")
+	}
+
+	return nil
 }
 
+// Start runs the HTTP server until the process receives a shutdown signal.
 func Start(options Options) {
-	server, serverCtx, serverStopCtx := NewServer(options)
+	ctx, stop := app.SignalContext(app.BaseContext())
+	defer stop()
 
-	// Set up graceful shutdown handling
-	setupGracefulShutdown(
-		server, serverCtx, serverStopCtx, options.Logger, options.ServerConfig.RequestTimeout,
-	)
+	if err := Run(ctx, options); err != nil {
+		logger := options.Logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+		logger.Error("HTTP server stopped with error", "error", err)
+	}
+}
 
-	options.Logger.Info("HTTP server started", "address", server.Addr)
+// Run starts the HTTP server through a lifecycle and blocks until ctx is done or the
+// server fails, then shuts it down gracefully. When Options.Lifecycle is set, the
+// server is appended to it so it starts after, and stops before, the app components.
+func Run(ctx context.Context, options Options) error {
+	if err := validateOptions(options); err != nil {
+		return fmt.Errorf("failed to start web server: %w", err)
+	}
 
-	// Start the HTTP server
-	err := server.ListenAndServe()
-	if err != nil && !errors.Is(err, nethttp.ErrServerClosed) {
-		options.Logger.Error("HTTP server failed to start", "error", err, "address", server.Addr)
-		serverStopCtx()
-		return
+	lifecycle := options.Lifecycle
+	if lifecycle == nil {
+		lifecycle = &app.Lifecycle{}
 	}
+	AppendToLifecycle(lifecycle, options)
+
+	stopShutdownLog := context.AfterFunc(ctx, func() {
+		options.Logger.Info("Shutdown requested", "cause", context.Cause(ctx))
+	})
+	defer stopShutdownLog()
+
+	return lifecycle.Run(ctx)
+}
+
+// AppendToLifecycle registers the HTTP server as a lifecycle hook. The server starts
+// listening on every configured listener in OnStart and is shut down gracefully in
+// OnStop, bounded by the request timeout. A serve failure after startup shuts the
+// whole lifecycle down.
+func AppendToLifecycle(lifecycle *app.Lifecycle, options Options) {
+	var server *nethttp.Server
+	var serverStopCtx context.CancelFunc
+
+	lifecycle.Append(app.Hook{
+		Name: "http server",
+		OnStart: func(context.Context) error {
+			var err error
+			server, _, serverStopCtx, err = NewServerE(options)
+			if err != nil {
+				return err
+			}
+
+			listeners, err := Listen(options.ServerConfig)
+			if err != nil {
+				options.Logger.Error("HTTP server failed to start", "error", err)
+				serverStopCtx()
+				return err
+			}
+
+			// Serve sets up a TLS config for HTTP/2 when there is none, so TLS is
+			// decided before the first listener is served
+			useTLS := server.TLSConfig != nil
+			for _, listener := range listeners {
+				address := listener.Addr().String()
+				options.Logger.Info(
+					"HTTP server started",
+					"network", listener.Addr().Network(),
+					"address", address,
+					"tls", useTLS,
+				)
+				go func() {
+					var err error
+					if useTLS {
+						err = server.ServeTLS(listener, "", "")
+					} else {
+						err = server.Serve(listener)
+					}
+					if err != nil && !errors.Is(err, nethttp.ErrServerClosed) {
+						options.Logger.Error("HTTP server failed", "error", err, "address", address)
+						lifecycle.Shutdown(fmt.Errorf("http server failed: %w", err))
+					}
+				}()
+			}
 
-	// Wait for a graceful shutdown to complete
-	<-serverCtx.Done()
-	options.Logger.Info("HTTP server shutdown complete")
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			defer serverStopCtx()
+
+			if err := server.Shutdown(ctx); err != nil {
+				if errors.Is(err, context.DeadlineExceeded) {
+					options.Logger.Warn(
+						"Graceful shutdown timed out",
+						"timeout", options.ServerConfig.RequestTimeout,
+					)
+				}
+				options.Logger.Error("Error during server shutdown", "error", err)
+				return err
+			}
+
+			options.Logger.Info("HTTP server shutdown complete")
+			return nil
+		},
+		StopTimeout: options.ServerConfig.RequestTimeout,
+	})
 }
 
 // buildGlobalMiddlewareChain wraps the router with middleware components from golibry/go-http
@@ -124,6 +290,12 @@ func buildGlobalMiddlewareChain(
 	// Start with the base mux as the handler
 	handler := nethttp.Handler(router)
 
+	// The request scope wraps the router directly, so it is closed right after the
+	// handler returns, including when the request timeout middleware is enabled
+	if options.RequestScope != nil {
+		handler = NewRequestScopeMiddleware(handler, options.RequestScope, logger)
+	}
+
 	if options.EnableRequestTimeout {
 		requestTimeoutOptions := middleware.TimeoutOptions{
 			Timeout: requestTimeout,
@@ -131,7 +303,10 @@ func buildGlobalMiddlewareChain(
 		if options.RequestTimeout != nil {
 			requestTimeoutOptions = *options.RequestTimeout
 		}
-		handler = middleware.NewTimeoutMiddleware(handler, logger, requestTimeoutOptions)
+		handler = newStreamingBypass(
+			middleware.NewTimeoutMiddleware(handler, logger, requestTimeoutOptions),
+			handler,
+		)
 	}
 
 	if !options.DisableCSRF {
@@ -139,7 +314,15 @@ func buildGlobalMiddlewareChain(
 		if options.CSRF != nil {
 			csrfOptions = *options.CSRF
 		}
-		handler = middleware.NewCSRFMiddleware(handler, logger, csrfOptions)
+		handler = newCSRFBypass(
+			middleware.NewCSRFMiddleware(handler, logger, csrfOptions),
+			handler,
+		)
+	}
+
+	// The route is looked up once for both bypasses, after the path is normalized
+	if options.EnableRequestTimeout || !options.DisableCSRF {
+		handler = newRouteLookup(handler, router, options.StreamingPaths)
 	}
 
 	if !options.DisablePathNormalizer {
@@ -156,58 +339,13 @@ func buildGlobalMiddlewareChain(
 		handler = middleware.NewHTTPAccessLogger(handler, logger, accessLogOptions)
 	}
 
+	if !options.DisableLogContext {
+		handler = NewLogContextMiddleware(handler)
+	}
+
 	if !options.DisableRecoverer {
 		handler = middleware.NewRecoverer(handler, ctx, logger)
 	}
 
 	return handler
 }
-
-// setupGracefulShutdown configures signal handling for graceful server shutdown.
-// It listens for SIGINT, SIGTERM, and SIGQUIT signals.
-func setupGracefulShutdown(
-	server *nethttp.Server,
-	serverCtx context.Context,
-	serverStopCtx context.CancelFunc,
-	logger *slog.Logger,
-	gracePeriod time.Duration,
-) {
-	// Create a signal channel and register for shutdown signals
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
-
-	go func() {
-		defer signal.Stop(sigChan)
-		defer close(sigChan)
-
-		// Wait for a shutdown signal or context cancellation
-		select {
-		case sig := <-sigChan:
-			logger.Info("Shutdown signal received", "signal", sig.String())
-		case <-serverCtx.Done():
-			return
-		}
-
-		// Create a shutdown context with timeout
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), gracePeriod)
-		defer cancel()
-
-		// Monitor for shutdown timeout
-		go func() {
-			<-shutdownCtx.Done()
-			if errors.Is(shutdownCtx.Err(), context.DeadlineExceeded) {
-				logger.Warn("Graceful shutdown timed out", "timeout", gracePeriod)
-			}
-		}()
-
-		// Perform a graceful shutdown
-		if err := server.Shutdown(shutdownCtx); err != nil {
-			logger.Error("Error during server shutdown", "error", err)
-		} else {
-			logger.Info("Server shutdown initiated successfully")
-		}
-
-		// Signal that shutdown is complete
-		serverStopCtx()
-	}()
-}
@@ -0,0 +1,39 @@
+package http
+
+import (
+	nethttp "net/http"
+	"strings"
+)
+
+// StreamingHandler marks handler as streaming its responses, e.g. Server-Sent Events,
+// so requests routed to it skip the request timeout. Register it on the router like
+// any other handler. It combines with CSRFExemptHandler in either order.
+func StreamingHandler(handler nethttp.Handler) nethttp.Handler {
+	return markHandler(handler, func(marks *RouteMarks) { marks.Streaming = true })
+}
+
+// newStreamingBypass serves streaming requests with streaming, skipping the middleware
+// of handler in between, e.g. the request timeout, which buffers or ends responses that
+// outlive it. Requests are streaming when newRouteLookup marked them so: routed to a
+// StreamingHandler or under one of the streaming paths. Request headers are not
+// trusted to opt in, so clients cannot escape the timeout of other routes.
+func newStreamingBypass(handler nethttp.Handler, streaming nethttp.Handler) nethttp.Handler {
+	return nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if routeMarksFromContext(r.Context()).Streaming {
+			streaming.ServeHTTP(w, r)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// hasPathPrefix reports whether path is prefix or is under it, e.g. /export/orders is
+// under /export but /exports is not.
+func hasPathPrefix(path string, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+
+	return len(path) == len(prefix) || strings.HasSuffix(prefix, "/") || path[len(prefix)] == '/'
+}
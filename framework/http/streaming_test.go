@@ -0,0 +1,160 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	nethttp "net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golibry/go-web-skeleton/framework/app"
+)
+
+func TestStreamingBypassSkipsTheTimeoutOfStreamingRoutesOnly(t *testing.T) {
+	router := nethttp.NewServeMux()
+	router.HandleFunc("GET /orders", func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		<-r.Context().Done()
+	})
+	router.Handle("GET /events", StreamingHandler(nethttp.HandlerFunc(
+		func(w nethttp.ResponseWriter, r *nethttp.Request) {
+			_, _ = io.WriteString(w, "data: event\n\n")
+		},
+	)))
+	router.HandleFunc("GET /export/orders", func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		_, _ = io.WriteString(w, "exported")
+	})
+	router.HandleFunc("GET /exports", func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		<-r.Context().Done()
+	})
+
+	handler := newRouteLookup(
+		newStreamingBypass(nethttp.TimeoutHandler(router, 20*time.Millisecond, "timed out"), router),
+		router,
+		[]string{"/export"},
+	)
+
+	tests := []struct {
+		path   string
+		accept string
+		status int
+	}{
+		{path: "/orders", status: nethttp.StatusServiceUnavailable},
+		{path: "/orders", accept: "text/event-stream", status: nethttp.StatusServiceUnavailable},
+		{path: "/events", accept: "text/event-stream", status: nethttp.StatusOK},
+		{path: "/export/orders", status: nethttp.StatusOK},
+		{path: "/exports", status: nethttp.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		request := httptest.NewRequest(nethttp.MethodGet, tt.path, nil)
+		if tt.accept != "" {
+			request.Header.Set("Accept", tt.accept)
+		}
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, request)
+
+		if recorder.Code != tt.status {
+			t.Errorf("GET %s (Accept %q) status = %d, want %d", tt.path, tt.accept, recorder.Code, tt.status)
+		}
+	}
+}
+
+func TestStreamsAreFlushedThroughTheMiddlewareChain(t *testing.T) {
+	service, err := app.NewLoggerService(
+		filepath.Join(t.TempDir(), "app.log"),
+		slog.LevelInfo,
+		app.LoggerOptions{},
+	)
+	if err != nil {
+		t.Fatalf("NewLoggerService() error = %v", err)
+	}
+	defer func() { _ = service.Close() }()
+
+	builder := app.NewResponseBuilderService(service.Logger(), nil)
+	received := make(chan struct{})
+	handlerErr := make(chan error, 1)
+	router := nethttp.NewServeMux()
+	router.Handle("GET /events", StreamingHandler(nethttp.HandlerFunc(
+		func(w nethttp.ResponseWriter, r *nethttp.Request) {
+			handlerErr <- builder.SSE(w, r, app.SSEOptions{HeartbeatInterval: -1},
+				func(stream *app.SSEStream) error {
+					if err := stream.Send(app.SSEEvent{Data: "first"}); err != nil {
+						return err
+					}
+					// The second event is only sent once the first one reached the client,
+					// past the request timeout
+					<-received
+					time.Sleep(30 * time.Millisecond)
+					return stream.Send(app.SSEEvent{Data: "second"})
+				},
+			)
+		},
+	)))
+	handler := buildGlobalMiddlewareChain(
+		router,
+		service.Logger(),
+		context.Background(),
+		MiddlewareOptions{EnableRequestTimeout: true},
+		20*time.Millisecond,
+	)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	response, err := nethttp.Get(server.URL + "/events")
+	if err != nil {
+		t.Fatalf("GET /events error = %v", err)
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	reader := bufio.NewReader(response.Body)
+	for _, want := range []string{"data: first\n", "\n"} {
+		line, err := reader.ReadString('\n')
+		if err != nil || line != want {
+			t.Fatalf("ReadString() = %q, %v, want %q", line, err, want)
+		}
+	}
+	close(received)
+	for _, want := range []string{"data: second\n", "\n"} {
+		line, err := reader.ReadString('\n')
+		if err != nil || line != want {
+			t.Fatalf("ReadString() = %q, %v, want %q", line, err, want)
+		}
+	}
+	if err := <-handlerErr; err != nil {
+		t.Fatalf("SSE() error = %v", err)
+	}
+}
+
+func TestRouteMarksCombineThroughTheMiddlewareChain(t *testing.T) {
+	slow := nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		time.Sleep(40 * time.Millisecond)
+		_, _ = io.WriteString(w, "done")
+	})
+	router := nethttp.NewServeMux()
+	router.Handle("POST /hooks/stream", StreamingHandler(CSRFExemptHandler(slow)))
+	router.Handle("POST /admin/stream", CSRFExemptHandler(StreamingHandler(slow)))
+	router.Handle("GET /events", StreamingHandler(slow))
+	handler := buildGlobalMiddlewareChain(
+		router,
+		slog.New(slog.DiscardHandler),
+		context.Background(),
+		MiddlewareOptions{EnableRequestTimeout: true},
+		20*time.Millisecond,
+	)
+
+	for _, target := range []string{"POST /hooks/stream", "POST /admin/stream", "GET /events/"} {
+		method, path, _ := strings.Cut(target, " ")
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+
+		if recorder.Code != nethttp.StatusOK || recorder.Body.String() != "done" {
+			t.Errorf("%s = %d %q, want the streamed response", target, recorder.Code, recorder.Body)
+		}
+	}
+}
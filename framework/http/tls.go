@@ -0,0 +1,173 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/golibry/go-web-skeleton/framework/config"
+)
+
+// tlsCipherSuites are the TLS 1.2 cipher suites the server accepts: ECDHE key exchange
+// with AEAD ciphers only. TLS 1.3 suites are not configurable and are all secure.
+var tlsCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+// NewTLSConfig returns the server TLS configuration: TLS 1.2 or newer with forward
+// secret AEAD cipher suites, the certificate of reloader and, when a client CA file
+// is configured, required client certificates.
+func NewTLSConfig(serverConfig config.HttpServer, reloader *CertificateReloader) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:       tls.VersionTLS12,
+		CipherSuites:     tlsCipherSuites,
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256, tls.CurveP384},
+		GetCertificate:   reloader.GetCertificate,
+		NextProtos:       []string{"h2", "http/1.1"},
+	}
+
+	if serverConfig.TLSClientCAFile != "" {
+		pem, err := os.ReadFile(serverConfig.TLSClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS client CA file: %w", err)
+		}
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf(
+				"failed to parse TLS client CA file %s: no PEM certificates found",
+				serverConfig.TLSClientCAFile,
+			)
+		}
+		tlsConfig.ClientCAs = clientCAs
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+// CertificateReloader serves a certificate pair loaded from files and replaces it when
+// the files change, so renewed certificates are picked up without a restart. Open
+// connections keep the certificate they were established with.
+type CertificateReloader struct {
+	certFile string
+	keyFile  string
+	logger   *slog.Logger
+
+	mu          sync.RWMutex
+	certificate *tls.Certificate
+	modTimes    [2]time.Time
+}
+
+// NewCertificateReloader loads the certificate pair of certFile and keyFile.
+func NewCertificateReloader(certFile string, keyFile string, logger *slog.Logger) (*CertificateReloader, error) {
+	reloader := &CertificateReloader{certFile: certFile, keyFile: keyFile, logger: logger}
+	if err := reloader.Reload(); err != nil {
+		return nil, err
+	}
+
+	return reloader, nil
+}
+
+// GetCertificate returns the current certificate, for tls.Config.GetCertificate.
+func (c *CertificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.certificate, nil
+}
+
+// Reload loads the certificate pair from the files. The current certificate is kept
+// when they cannot be loaded, e.g. while a renewal has written only one of them.
+func (c *CertificateReloader) Reload() error {
+	modTimes, err := c.fileModTimes()
+	if err != nil {
+		return err
+	}
+
+	certificate, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate pair: %w", err)
+	}
+
+	c.mu.Lock()
+	c.certificate = &certificate
+	c.modTimes = modTimes
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Watch reloads the certificate pair on SIGHUP and, when interval is positive, when
+// the modification time of either file changes. It blocks until ctx is done.
+func (c *CertificateReloader) Watch(ctx context.Context, interval time.Duration) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	var ticks <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	for {
+		select {
+		case <-signals:
+			c.reload("signal")
+		case <-ticks:
+			if c.changed() {
+				c.reload("file change")
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *CertificateReloader) reload(trigger string) {
+	if err := c.Reload(); err != nil {
+		c.logger.Error("Failed to reload TLS certificate", "error", err, "trigger", trigger)
+		return
+	}
+
+	c.logger.Info("TLS certificate reloaded", "cert_file", c.certFile, "trigger", trigger)
+}
+
+// changed reports whether either file was modified since the last load.
+func (c *CertificateReloader) changed() bool {
+	modTimes, err := c.fileModTimes()
+	if err != nil {
+		return false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return modTimes != c.modTimes
+}
+
+func (c *CertificateReloader) fileModTimes() ([2]time.Time, error) {
+	var modTimes [2]time.Time
+	for i, path := range []string{c.certFile, c.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return modTimes, fmt.Errorf("failed to stat TLS certificate file: %w", err)
+		}
+		modTimes[i] = info.ModTime()
+	}
+
+	return modTimes, nil
+}
@@ -0,0 +1,243 @@
+package http
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log/slog"
+	"math/big"
+	nethttp "net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golibry/go-web-skeleton/framework/config"
+)
+
+// writeCertificatePair writes a self-signed certificate for commonName and its key to
+// cert.pem and key.pem in dir and returns their paths.
+func writeCertificatePair(t *testing.T, dir string, commonName string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey() error = %v", err)
+	}
+
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeFile(t, certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	writeFile(t, keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}))
+
+	return certFile, keyFile
+}
+
+func writeFile(t *testing.T, path string, content []byte) {
+	t.Helper()
+
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+}
+
+func certificateName(t *testing.T, reloader *CertificateReloader) string {
+	t.Helper()
+
+	certificate, err := reloader.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("GetCertificate() error = %v", err)
+	}
+	leaf, err := x509.ParseCertificate(certificate.Certificate[0])
+	if err != nil {
+		t.Fatalf("ParseCertificate() error = %v", err)
+	}
+
+	return leaf.Subject.CommonName
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificatePair(t, dir, "server")
+	invalidCAFile := filepath.Join(dir, "invalid-ca.pem")
+	writeFile(t, invalidCAFile, []byte("not a certificate"))
+
+	reloader, err := NewCertificateReloader(certFile, keyFile, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatalf("NewCertificateReloader() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		clientCA   string
+		clientAuth tls.ClientAuthType
+		err        string
+	}{
+		{name: "server certificate only", clientAuth: tls.NoClientCert},
+		{name: "client certificates", clientCA: certFile, clientAuth: tls.RequireAndVerifyClientCert},
+		{name: "missing client CA file", clientCA: filepath.Join(dir, "missing.pem"), err: "failed to read"},
+		{name: "invalid client CA file", clientCA: invalidCAFile, err: "no PEM certificates found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverConfig := config.HttpServer{TLSCertFile: certFile, TLSKeyFile: keyFile, TLSClientCAFile: tt.clientCA}
+
+			tlsConfig, err := NewTLSConfig(serverConfig, reloader)
+
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("NewTLSConfig() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewTLSConfig() error = %v", err)
+			}
+			if tlsConfig.MinVersion != tls.VersionTLS12 || tlsConfig.ClientAuth != tt.clientAuth {
+				t.Fatalf("config = %+v, want TLS 1.2 and client auth %v", tlsConfig, tt.clientAuth)
+			}
+			if (tlsConfig.ClientCAs != nil) != (tt.clientCA != "") {
+				t.Fatalf("ClientCAs = %v, want them only with a client CA file", tlsConfig.ClientCAs)
+			}
+			if len(tlsConfig.NextProtos) == 0 || tlsConfig.NextProtos[0] != "h2" {
+				t.Fatalf("NextProtos = %v, want h2 first", tlsConfig.NextProtos)
+			}
+		})
+	}
+}
+
+func TestCertificateReloaderSwapsChangedPairs(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificatePair(t, dir, "first")
+
+	reloader, err := NewCertificateReloader(certFile, keyFile, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatalf("NewCertificateReloader() error = %v", err)
+	}
+	if name := certificateName(t, reloader); name != "first" {
+		t.Fatalf("certificate = %s, want first", name)
+	}
+	if reloader.changed() {
+		t.Fatal("changed() = true, want false for the loaded files")
+	}
+
+	// A renewal that has written the key only leaves a mismatched pair.
+	renewed := t.TempDir()
+	_, renewedKeyFile := writeCertificatePair(t, renewed, "second")
+	key, err := os.ReadFile(renewedKeyFile)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	writeFile(t, keyFile, key)
+	future := time.Now().Add(time.Minute)
+	_ = os.Chtimes(keyFile, future, future)
+
+	if !reloader.changed() {
+		t.Fatal("changed() = false, want true for the rewritten key")
+	}
+	if err := reloader.Reload(); err == nil {
+		t.Fatal("Reload() error = nil, want the mismatched pair rejected")
+	}
+	if name := certificateName(t, reloader); name != "first" {
+		t.Fatalf("certificate = %s, want first kept after the failed reload", name)
+	}
+
+	writeCertificatePair(t, dir, "second")
+	if err := reloader.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if name := certificateName(t, reloader); name != "second" {
+		t.Fatalf("certificate = %s, want second", name)
+	}
+
+	if _, err := NewCertificateReloader(filepath.Join(dir, "missing.pem"), keyFile, nil); err == nil {
+		t.Fatal("NewCertificateReloader() error = nil, want the missing certificate reported")
+	}
+}
+
+func TestNewServerEReturnsErrors(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificatePair(t, dir, "server")
+	registerRoutes := func(*nethttp.ServeMux) {}
+	logger := slog.New(slog.DiscardHandler)
+
+	tests := []struct {
+		name    string
+		options Options
+		err     string
+	}{
+		{name: "missing routes", options: Options{Logger: logger}, err: "register routes function is required"},
+		{name: "missing logger", options: Options{RegisterRoutes: registerRoutes}, err: "logger is required"},
+		{
+			name: "missing certificate",
+			options: Options{
+				RegisterRoutes: registerRoutes,
+				Logger:         logger,
+				ServerConfig:   config.HttpServer{TLSCertFile: filepath.Join(dir, "missing.pem"), TLSKeyFile: keyFile},
+			},
+			err: "failed to stat TLS certificate file",
+		},
+		{
+			name: "TLS",
+			options: Options{
+				RegisterRoutes: registerRoutes,
+				Logger:         logger,
+				ServerConfig:   config.HttpServer{TLSCertFile: certFile, TLSKeyFile: keyFile},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _, cancel, err := NewServerE(tt.options)
+
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("NewServerE() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewServerE() error = %v", err)
+			}
+			defer cancel()
+			if server.TLSConfig == nil || server.TLSConfig.GetCertificate == nil {
+				t.Fatalf("TLSConfig = %+v, want the reloaded certificate", server.TLSConfig)
+			}
+		})
+	}
+
+	err := Run(context.Background(), Options{Logger: logger})
+	if err == nil || err.Error() != "failed to start web server: register routes function is required" {
+		t.Fatalf("Run() error = %v, want the invalid options reported once", err)
+	}
+
+	defer func() {
+		if recovered := recover(); recovered == nil {
+			t.Fatal("NewServer() did not panic, want the invalid options")
+		}
+	}()
+	NewServer(Options{Logger: logger})
+}
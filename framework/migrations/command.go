@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"strings"
 
@@ -13,6 +14,7 @@ import (
 	migrationscli "github.com/golibry/go-migrations/cli"
 	"github.com/golibry/go-migrations/execution"
 	gomigration "github.com/golibry/go-migrations/migration"
+	"github.com/golibry/go-web-skeleton/framework/app"
 	"github.com/golibry/go-web-skeleton/framework/config"
 )
 
@@ -36,6 +38,9 @@ var (
 type Migrations struct {
 	cli.CommandWithoutFlags
 	Options Options
+
+	// DatabaseName targets a named database, the command id becomes "migrations:<name>".
+	DatabaseName string
 }
 
 func NewCommand(database config.Database) *Migrations {
@@ -46,7 +51,18 @@ func NewCommand(database config.Database) *Migrations {
 	}
 }
 
+// NewNamedCommand creates the migrations command of a named database, using its own
+// migrations directory and executions table.
+func NewNamedCommand(name string, database config.Database) *Migrations {
+	command := NewCommand(database)
+	command.DatabaseName = name
+
+	return command
+}
+
 type Options struct {
+	// Context bounds the migrations run, app.BaseContext when nil, which is canceled on
+	// shutdown signals when the command is run by the CLI bootstrap.
 	Context context.Context
 
 	Database config.Database
@@ -59,6 +75,13 @@ type Options struct {
 	MigrationsDir   string
 	ExecutionsTable string
 
+	// ConnectRetry configures how long a database opened from DSN is waited for before
+	// running migrations. It defaults to the retry settings of Database.
+	ConnectRetry app.ConnectRetryPolicy
+
+	// Logger receives the connection attempts, slog.Default when nil.
+	Logger *slog.Logger
+
 	// MigrationsFactory must return migrations that need direct access to the SQL handle.
 	// Auto-registered migrations are used when this is nil.
 	MigrationsFactory func(db *sql.DB, ctx context.Context) []gomigration.Migration
@@ -88,10 +111,18 @@ func (e *MigrationsExitError) ExitCode() int {
 }
 
 func (c *Migrations) Id() string {
+	if c.DatabaseName != "" {
+		return "migrations:" + c.DatabaseName
+	}
+
 	return "migrations"
 }
 
 func (c *Migrations) Description() string {
+	if c.DatabaseName != "" {
+		return fmt.Sprintf("Handles migrations of the %s database", c.DatabaseName)
+	}
+
 	return "Handles database migrations"
 }
 
@@ -139,6 +170,12 @@ func NewRuntime(options Options) (*Runtime, error) {
 	if err != nil {
 		return nil, fmt.Errorf("could not build migrations runtime: failed to build database connection: %w", err)
 	}
+	if options.DB == nil {
+		if err = app.PingWithRetry(options.Context, db, options.ConnectRetry, options.Logger); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("could not build migrations runtime: failed to connect to database: %w", err)
+		}
+	}
 
 	dirPath, err := gomigration.NewMigrationsDirPath(options.MigrationsDir)
 	if err != nil {
@@ -196,7 +233,7 @@ func (o Options) Validate() error {
 
 func (o Options) withDefaults() Options {
 	if o.Context == nil {
-		o.Context = context.Background()
+		o.Context = app.BaseContext()
 	}
 	if o.Driver == "" {
 		o.Driver = o.Database.Driver
@@ -220,6 +257,9 @@ func (o Options) withDefaults() Options {
 	if o.ExecutionsTable == "" {
 		o.ExecutionsTable = defaultExecutionsTable
 	}
+	if o.ConnectRetry == (app.ConnectRetryPolicy{}) {
+		o.ConnectRetry = app.ConnectRetryPolicyFromConfig(o.Database)
+	}
 
 	return o
 }
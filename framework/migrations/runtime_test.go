@@ -111,6 +111,20 @@ func TestNewCommandUsesDatabaseConfig(t *testing.T) {
 	}
 }
 
+func TestNewNamedCommandTargetsDatabase(t *testing.T) {
+	database := config.Database{Dsn: "audit-dsn", Driver: DriverPostgres}
+	database.Migrations.MigrationsDirPath = "migrations/audit"
+
+	command := NewNamedCommand("audit", database)
+
+	if command.Id() != "migrations:audit" {
+		t.Fatalf("Id() = %q, want migrations:audit", command.Id())
+	}
+	if command.Options.withDefaults().MigrationsDir != "migrations/audit" {
+		t.Fatalf("MigrationsDir = %q, want migrations/audit", command.Options.withDefaults().MigrationsDir)
+	}
+}
+
 func TestUnsupportedDriverErrorSuggestsInstalledDriver(t *testing.T) {
 	err := repositoryBuildMismatchError(DriverPostgres, DriverMySQL)
 
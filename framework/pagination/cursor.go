@@ -0,0 +1,130 @@
+package pagination
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Cursor is the keyset position of a page: the key of the last item of the previous
+// page, or of the first item of the next page when Backward.
+type Cursor struct {
+	// Values are the keyset values: strings, booleans, integers, decoded as int64, and
+	// floats, decoded as float64. Other types, e.g. time.Time, are rejected because
+	// they would come back as strings. Pass times formatted like their column stores
+	// them instead, so the keyset comparison matches, e.g. the text timestamps of SQLite.
+	// nil is rejected too, as no row compares greater or less than NULL: sort nullable
+	// columns by an expression replacing NULL, e.g. "COALESCE(shipped_at, '')", and
+	// return the same replacement from the key of the items.
+	Values   []any
+	Backward bool
+}
+
+type cursorPayload struct {
+	Values   []any  `json:"v"`
+	Backward bool   `json:"b,omitempty"`
+	Scope    string `json:"s"`
+}
+
+// CursorScope returns the scope of the cursors of r: its path and its query parameters
+// other than the pagination ones, e.g. filters and sort. A cursor is only accepted by
+// requests of the scope it was issued for, not by other endpoints sharing the secret.
+func (p *Paginator) CursorScope(r *http.Request) string {
+	query := r.URL.Query()
+	query.Del(p.options.LimitParam)
+	query.Del(p.options.OffsetParam)
+	query.Del(p.options.CursorParam)
+
+	return r.URL.Path + "?" + query.Encode()
+}
+
+// EncodeCursor returns cursor as an opaque URL safe string signed with the secret and
+// bound to scope, see CursorScope.
+func (p *Paginator) EncodeCursor(scope string, cursor Cursor) (string, error) {
+	if len(p.options.Secret) == 0 {
+		return "", ErrMissingSecret
+	}
+	for _, v := range cursor.Values {
+		switch v.(type) {
+		case string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+			float32, float64:
+		default:
+			return "", fmt.Errorf("failed to encode cursor: unsupported value type %T", v)
+		}
+	}
+
+	payload, err := json.Marshal(cursorPayload{
+		Values:   cursor.Values,
+		Backward: cursor.Backward,
+		Scope:    p.scopeHash(scope),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + p.sign(encoded), nil
+}
+
+// DecodeCursor verifies and decodes a cursor returned by EncodeCursor for scope.
+func (p *Paginator) DecodeCursor(scope string, value string) (Cursor, error) {
+	if len(p.options.Secret) == 0 {
+		return Cursor{}, ErrMissingSecret
+	}
+
+	encoded, signature, ok := strings.Cut(value, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(p.sign(encoded))) {
+		return Cursor{}, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var decoded cursorPayload
+	if err := decoder.Decode(&decoded); err != nil || len(decoded.Values) == 0 {
+		return Cursor{}, ErrInvalidCursor
+	}
+	if slices.Contains(decoded.Values, nil) {
+		return Cursor{}, ErrInvalidCursor
+	}
+	if !hmac.Equal([]byte(decoded.Scope), []byte(p.scopeHash(scope))) {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	for i, v := range decoded.Values {
+		number, ok := v.(json.Number)
+		if !ok {
+			continue
+		}
+		if integer, err := number.Int64(); err == nil {
+			decoded.Values[i] = integer
+		} else if float, err := number.Float64(); err == nil {
+			decoded.Values[i] = float
+		}
+	}
+
+	return Cursor{Values: decoded.Values, Backward: decoded.Backward}, nil
+}
+
+// scopeHash returns a short digest of scope, which keeps the filters out of the cursor.
+func (p *Paginator) scopeHash(scope string) string {
+	sum := sha256.Sum256([]byte(scope))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:8])
+}
+
+func (p *Paginator) sign(encoded string) string {
+	mac := hmac.New(sha256.New, p.options.Secret)
+	mac.Write([]byte(encoded))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
@@ -0,0 +1,121 @@
+package pagination
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Placeholder returns the SQL placeholder of the nth query argument, counting from 1.
+type Placeholder func(n int) string
+
+// QuestionPlaceholder is the placeholder of the MySQL and SQLite drivers.
+func QuestionPlaceholder(int) string {
+	return "?"
+}
+
+// DollarPlaceholder is the placeholder of the Postgres drivers.
+func DollarPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// PlaceholderFor returns the placeholder of a database/sql driver name.
+func PlaceholderFor(driver string) Placeholder {
+	switch driver {
+	case "postgres", "pgx":
+		return DollarPlaceholder
+	default:
+		return QuestionPlaceholder
+	}
+}
+
+// KeysetColumn is a column of the sort key. The last column must be unique, usually
+// the primary key, so the key orders rows totally.
+type KeysetColumn struct {
+	// Name is the column as written in SQL. It is not escaped, so it must never come
+	// from the request.
+	Name string
+	Desc bool
+}
+
+// Keyset builds the SQL of keyset pagination, which seeks past the key of the cursor
+// instead of scanning the skipped rows like OFFSET. The comparisons are expanded to
+// support mixed sort directions on every supported database:
+//
+//	(a > ?) OR (a = ? AND b < ?)
+type Keyset struct {
+	Columns     []KeysetColumn
+	Placeholder Placeholder
+}
+
+// Where returns the condition selecting the rows after cursor, or before it for
+// backward cursors, with its arguments. The placeholders are numbered from firstArg,
+// the position of the first argument in the query. It returns an empty condition for
+// a nil cursor, and an error for cursors with nil values, see Cursor.Values.
+func (k Keyset) Where(cursor *Cursor, firstArg int) (string, []any, error) {
+	if cursor == nil {
+		return "", nil, nil
+	}
+	if slices.Contains(cursor.Values, nil) {
+		return "", nil, fmt.Errorf("%w: nil cursor value, sort nullable columns by COALESCE", ErrInvalidCursor)
+	}
+	if len(cursor.Values) != len(k.Columns) {
+		return "", nil, fmt.Errorf(
+			"%w: %d cursor values for %d keyset columns",
+			ErrInvalidCursor,
+			len(cursor.Values),
+			len(k.Columns),
+		)
+	}
+
+	placeholder := k.Placeholder
+	if placeholder == nil {
+		placeholder = QuestionPlaceholder
+	}
+
+	var (
+		args      []any
+		disjuncts []string
+	)
+	for i, column := range k.Columns {
+		conditions := make([]string, 0, i+1)
+		for j := range i {
+			args = append(args, cursor.Values[j])
+			conditions = append(
+				conditions,
+				fmt.Sprintf("%s = %s", k.Columns[j].Name, placeholder(firstArg+len(args)-1)),
+			)
+		}
+
+		operator := ">"
+		if column.Desc != cursor.Backward {
+			operator = "<"
+		}
+		args = append(args, cursor.Values[i])
+		conditions = append(
+			conditions,
+			fmt.Sprintf("%s %s %s", column.Name, operator, placeholder(firstArg+len(args)-1)),
+		)
+		disjuncts = append(disjuncts, "("+strings.Join(conditions, " AND ")+")")
+	}
+
+	return "(" + strings.Join(disjuncts, " OR ") + ")", args, nil
+}
+
+// OrderBy returns the ORDER BY list of the page of cursor, reversed for backward
+// cursors so the rows nearest to the cursor come first.
+func (k Keyset) OrderBy(cursor *Cursor) string {
+	backward := cursor != nil && cursor.Backward
+
+	columns := make([]string, 0, len(k.Columns))
+	for _, column := range k.Columns {
+		direction := "ASC"
+		if column.Desc != backward {
+			direction = "DESC"
+		}
+		columns = append(columns, column.Name+" "+direction)
+	}
+
+	return strings.Join(columns, ", ")
+}
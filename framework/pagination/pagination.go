@@ -0,0 +1,282 @@
+package pagination
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/golibry/go-web-skeleton/framework/app"
+)
+
+const (
+	DefaultLimit    = 20
+	DefaultMaxLimit = 100
+)
+
+var (
+	ErrMissingSecret    = errors.New("missing pagination cursor secret")
+	ErrInvalidCursor    = errors.New("invalid or tampered cursor")
+	ErrInvalidLimit     = errors.New("must be a positive integer")
+	ErrInvalidOffset    = errors.New("must be a non-negative integer")
+	ErrOffsetTooLarge   = errors.New("offset is too large, use cursor pagination")
+	ErrCursorWithOffset = errors.New("cursor and offset cannot be combined")
+)
+
+// Options configures a Paginator.
+type Options struct {
+	// DefaultLimit is the page size without a limit parameter, DefaultLimit when zero.
+	DefaultLimit int
+
+	// MaxLimit caps the limit parameter, DefaultMaxLimit when zero. Larger limits are
+	// lowered to it.
+	MaxLimit int
+
+	// MaxOffset rejects deeper offsets, which scan every skipped row. Zero allows any.
+	MaxOffset int
+
+	// Secret signs the cursors, so clients cannot forge them. Cursor pagination is
+	// rejected without it.
+	Secret []byte
+
+	// Parameter names, "limit", "offset" and "cursor" by default.
+	LimitParam  string
+	OffsetParam string
+	CursorParam string
+}
+
+// Paginator parses the pagination parameters of requests and builds their pages.
+type Paginator struct {
+	options Options
+}
+
+// Params are the pagination parameters of a request.
+type Params struct {
+	Limit  int
+	Offset int
+
+	// Cursor is the position of the page, nil for the first page and offset pagination.
+	Cursor *Cursor
+}
+
+// FetchLimit is the number of rows to query: one more than Limit, which tells the page
+// builders whether another page follows.
+func (p Params) FetchLimit() int {
+	return p.Limit + 1
+}
+
+// Page is the standard envelope of paginated list responses.
+type Page[T any] struct {
+	Items []T `json:"items"`
+	Limit int `json:"limit"`
+
+	// Offset is set for offset pagination.
+	Offset *int `json:"offset,omitempty"`
+
+	// Total is the number of items of every page, when known.
+	Total *int64 `json:"total,omitempty"`
+
+	Links Links `json:"links"`
+}
+
+// Links are the URLs of the pages around a page, empty when there is none.
+type Links struct {
+	Self  string `json:"self"`
+	First string `json:"first,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+}
+
+// New returns a Paginator for options.
+func New(options Options) *Paginator {
+	if options.DefaultLimit <= 0 {
+		options.DefaultLimit = DefaultLimit
+	}
+	if options.MaxLimit <= 0 {
+		options.MaxLimit = DefaultMaxLimit
+	}
+	options.DefaultLimit = min(options.DefaultLimit, options.MaxLimit)
+	if options.LimitParam == "" {
+		options.LimitParam = "limit"
+	}
+	if options.OffsetParam == "" {
+		options.OffsetParam = "offset"
+	}
+	if options.CursorParam == "" {
+		options.CursorParam = "cursor"
+	}
+
+	return &Paginator{options: options}
+}
+
+// Parse reads the pagination parameters of r. Invalid parameters are returned as
+// *app.BindError, which problem responses report as 400 Bad Request.
+func (p *Paginator) Parse(r *http.Request) (Params, error) {
+	query := r.URL.Query()
+	params := Params{Limit: p.options.DefaultLimit}
+
+	if value := query.Get(p.options.LimitParam); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return Params{}, p.paramError(p.options.LimitParam, ErrInvalidLimit)
+		}
+		params.Limit = min(limit, p.options.MaxLimit)
+	}
+
+	if value := query.Get(p.options.OffsetParam); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return Params{}, p.paramError(p.options.OffsetParam, ErrInvalidOffset)
+		}
+		if p.options.MaxOffset > 0 && offset > p.options.MaxOffset {
+			return Params{}, p.paramError(p.options.OffsetParam, ErrOffsetTooLarge)
+		}
+		params.Offset = offset
+	}
+
+	if value := query.Get(p.options.CursorParam); value != "" {
+		if params.Offset > 0 {
+			return Params{}, p.paramError(p.options.CursorParam, ErrCursorWithOffset)
+		}
+		cursor, err := p.DecodeCursor(p.CursorScope(r), value)
+		if err != nil {
+			return Params{}, p.paramError(p.options.CursorParam, err)
+		}
+		params.Cursor = &cursor
+	}
+
+	return params, nil
+}
+
+// OffsetPage returns the page of items, queried with params.FetchLimit from
+// params.Offset. total is the number of items of every page, negative when unknown.
+func OffsetPage[T any](p *Paginator, r *http.Request, params Params, items []T, total int64) Page[T] {
+	hasNext := len(items) > params.Limit
+	if hasNext {
+		items = items[:params.Limit]
+	}
+
+	offset := params.Offset
+	page := Page[T]{Items: nonNil(items), Limit: params.Limit, Offset: &offset}
+	if total >= 0 {
+		page.Total = &total
+		hasNext = int64(offset+len(items)) < total
+	}
+
+	page.Links.Self = p.link(r, map[string]string{p.options.OffsetParam: strconv.Itoa(offset)})
+	page.Links.First = p.link(r, map[string]string{p.options.OffsetParam: ""})
+	if offset > 0 {
+		prev := max(offset-params.Limit, 0)
+		page.Links.Prev = p.link(r, map[string]string{p.options.OffsetParam: strconv.Itoa(prev)})
+	}
+	if hasNext {
+		next := strconv.Itoa(offset + params.Limit)
+		page.Links.Next = p.link(r, map[string]string{p.options.OffsetParam: next})
+	}
+
+	return page
+}
+
+// CursorPage returns the page of items, queried with params.FetchLimit in the order of
+// Keyset.OrderBy for params.Cursor, which reverses the rows of backward pages. key
+// returns the keyset values of an item, in the order of the Keyset columns, of the
+// types supported by Cursor.Values.
+func CursorPage[T any](
+	p *Paginator,
+	r *http.Request,
+	params Params,
+	items []T,
+	key func(item T) []any,
+) (Page[T], error) {
+	hasMore := len(items) > params.Limit
+	if hasMore {
+		items = items[:params.Limit]
+	}
+
+	backward := params.Cursor != nil && params.Cursor.Backward
+	if backward {
+		reversed := make([]T, len(items))
+		for i, item := range items {
+			reversed[len(items)-1-i] = item
+		}
+		items = reversed
+	}
+
+	page := Page[T]{Items: nonNil(items), Limit: params.Limit}
+	page.Links.Self = p.link(r, nil)
+	page.Links.First = p.link(r, map[string]string{p.options.CursorParam: ""})
+
+	hasPrev, hasNext := params.Cursor != nil, hasMore
+	if backward {
+		hasPrev, hasNext = hasMore, true
+	}
+	if len(items) == 0 {
+		return page, nil
+	}
+
+	scope := p.CursorScope(r)
+	if hasPrev {
+		cursor, err := p.EncodeCursor(scope, Cursor{Values: key(items[0]), Backward: true})
+		if err != nil {
+			return Page[T]{}, err
+		}
+		page.Links.Prev = p.link(r, map[string]string{p.options.CursorParam: cursor})
+	}
+	if hasNext {
+		cursor, err := p.EncodeCursor(scope, Cursor{Values: key(items[len(items)-1])})
+		if err != nil {
+			return Page[T]{}, err
+		}
+		page.Links.Next = p.link(r, map[string]string{p.options.CursorParam: cursor})
+	}
+
+	return page, nil
+}
+
+// SetLinkHeader sets the RFC 8288 Link header of the first, previous and next pages.
+func SetLinkHeader(w http.ResponseWriter, links Links) {
+	var values []string
+	for _, link := range []struct{ url, rel string }{
+		{links.First, "first"},
+		{links.Prev, "prev"},
+		{links.Next, "next"},
+	} {
+		if link.url != "" {
+			values = append(values, fmt.Sprintf(`<%s>; rel="%s"`, link.url, link.rel))
+		}
+	}
+
+	if len(values) > 0 {
+		w.Header().Set("Link", strings.Join(values, ", "))
+	}
+}
+
+// link returns the URL of the request with the query parameters of set replaced,
+// removed when empty.
+func (p *Paginator) link(r *http.Request, set map[string]string) string {
+	query := r.URL.Query()
+	for name, value := range set {
+		if value == "" {
+			query.Del(name)
+			continue
+		}
+		query.Set(name, value)
+	}
+
+	link := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	return link.String()
+}
+
+func (p *Paginator) paramError(param string, err error) error {
+	return &app.BindError{Source: app.BindSourceQuery, Field: param, Err: err}
+}
+
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+
+	return items
+}
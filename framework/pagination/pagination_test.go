@@ -0,0 +1,271 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/golibry/go-web-skeleton/framework/app"
+)
+
+func TestParseEnforcesLimits(t *testing.T) {
+	paginator := New(Options{MaxLimit: 50, MaxOffset: 1000, Secret: []byte("secret")})
+
+	params, err := paginator.Parse(httptest.NewRequest(http.MethodGet, "/orders?limit=500&offset=40", nil))
+	if err != nil || params.Limit != 50 || params.Offset != 40 || params.Cursor != nil {
+		t.Fatalf("Parse() = %+v, %v, want the limit lowered to the maximum", params, err)
+	}
+
+	tests := []struct {
+		query string
+		param string
+		want  error
+	}{
+		{query: "limit=0", param: "limit", want: ErrInvalidLimit},
+		{query: "offset=-1", param: "offset", want: ErrInvalidOffset},
+		{query: "offset=1001", param: "offset", want: ErrOffsetTooLarge},
+		{query: "cursor=abc.def", param: "cursor", want: ErrInvalidCursor},
+		{query: "offset=20&cursor=abc", param: "cursor", want: ErrCursorWithOffset},
+	}
+	for _, tt := range tests {
+		_, err := paginator.Parse(httptest.NewRequest(http.MethodGet, "/orders?"+tt.query, nil))
+
+		var bindErr *app.BindError
+		if !errors.As(err, &bindErr) || bindErr.Field != tt.param || !errors.Is(err, tt.want) {
+			t.Errorf("Parse(%s) error = %v, want %v on %s", tt.query, err, tt.want, tt.param)
+		}
+	}
+}
+
+func TestCursorsAreSigned(t *testing.T) {
+	paginator := New(Options{Secret: []byte("secret")})
+	request := httptest.NewRequest(http.MethodGet, "/orders?status=open&sort=-created_at&limit=10", nil)
+	scope := paginator.CursorScope(request)
+	want := Cursor{Values: []any{"2026-05-01T10:00:00Z", int64(1) << 60}, Backward: true}
+
+	encoded, err := paginator.EncodeCursor(scope, want)
+	if err != nil {
+		t.Fatalf("EncodeCursor() error = %v", err)
+	}
+	cursor, err := paginator.DecodeCursor(scope, encoded)
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	if !reflect.DeepEqual(cursor, want) {
+		t.Fatalf("cursor = %#v, want the encoded values", cursor)
+	}
+
+	other := New(Options{Secret: []byte("other")})
+	if _, err := other.DecodeCursor(scope, encoded); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("DecodeCursor() error = %v, want a cursor signed with another secret rejected", err)
+	}
+	if _, err := New(Options{}).EncodeCursor(scope, cursor); !errors.Is(err, ErrMissingSecret) {
+		t.Fatalf("EncodeCursor() error = %v, want ErrMissingSecret", err)
+	}
+	if _, err := paginator.EncodeCursor(scope, Cursor{Values: []any{time.Now()}}); err == nil {
+		t.Fatal("EncodeCursor() error = nil, want time.Time values rejected")
+	}
+
+	// A cursor signed before nil values were rejected
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"v":[null,7],"s":"` + paginator.scopeHash(scope) + `"}`))
+	if _, err := paginator.DecodeCursor(scope, payload+"."+paginator.sign(payload)); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("DecodeCursor() error = %v, want a cursor with a nil value rejected", err)
+	}
+}
+
+func TestCursorsAreBoundToTheirScope(t *testing.T) {
+	paginator := New(Options{Secret: []byte("secret")})
+	issued := httptest.NewRequest(http.MethodGet, "/orders?status=open&sort=-created_at", nil)
+	encoded, err := paginator.EncodeCursor(paginator.CursorScope(issued), Cursor{Values: []any{int64(7)}})
+	if err != nil {
+		t.Fatalf("EncodeCursor() error = %v", err)
+	}
+
+	tests := []struct {
+		query   string
+		wantErr bool
+	}{
+		{query: "/orders?sort=-created_at&status=open&limit=5&cursor=" + encoded},
+		{query: "/users?status=open&sort=-created_at&cursor=" + encoded, wantErr: true},
+		{query: "/orders?status=closed&sort=-created_at&cursor=" + encoded, wantErr: true},
+		{query: "/orders?status=open&sort=total&cursor=" + encoded, wantErr: true},
+	}
+	for _, tt := range tests {
+		_, err := paginator.Parse(httptest.NewRequest(http.MethodGet, tt.query, nil))
+		if tt.wantErr != errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Parse(%s) error = %v, want ErrInvalidCursor %v", tt.query, err, tt.wantErr)
+		}
+	}
+}
+
+func TestOffsetPageLinks(t *testing.T) {
+	paginator := New(Options{})
+	request := httptest.NewRequest(http.MethodGet, "/orders?status=open&limit=2&offset=2", nil)
+	params, _ := paginator.Parse(request)
+
+	page := OffsetPage(paginator, request, params, []int{3, 4, 5}, -1)
+
+	if !reflect.DeepEqual(page.Items, []int{3, 4}) || page.Total != nil {
+		t.Fatalf("page = %+v, want the extra row trimmed", page)
+	}
+	want := Links{
+		Self:  "/orders?limit=2&offset=2&status=open",
+		First: "/orders?limit=2&status=open",
+		Prev:  "/orders?limit=2&offset=0&status=open",
+		Next:  "/orders?limit=2&offset=4&status=open",
+	}
+	if page.Links != want {
+		t.Fatalf("links = %+v, want %+v", page.Links, want)
+	}
+
+	page = OffsetPage(paginator, request, params, []int{3, 4}, 4)
+	if page.Links.Next != "" || *page.Total != 4 {
+		t.Fatalf("page = %+v, want the last page", page)
+	}
+
+	recorder := httptest.NewRecorder()
+	SetLinkHeader(recorder, want)
+	wantHeader := `</orders?limit=2&status=open>; rel="first", ` +
+		`</orders?limit=2&offset=0&status=open>; rel="prev", ` +
+		`</orders?limit=2&offset=4&status=open>; rel="next"`
+	if recorder.Header().Get("Link") != wantHeader {
+		t.Fatalf("Link = %q, want %q", recorder.Header().Get("Link"), wantHeader)
+	}
+}
+
+type pageRow struct {
+	Score int
+	ID    int
+}
+
+// queryRows runs the keyset query of params on rows like a database would, ordered by
+// score descending and id ascending.
+func queryRows(t *testing.T, keyset Keyset, params Params, rows []pageRow) []pageRow {
+	t.Helper()
+
+	if _, _, err := keyset.Where(params.Cursor, 1); err != nil {
+		t.Fatalf("Where() error = %v", err)
+	}
+
+	var result []pageRow
+	for _, row := range rows {
+		if params.Cursor == nil || afterCursor(row, params.Cursor) {
+			result = append(result, row)
+		}
+	}
+	backward := params.Cursor != nil && params.Cursor.Backward
+	sort.Slice(result, func(i, j int) bool {
+		less := result[i].Score > result[j].Score ||
+			result[i].Score == result[j].Score && result[i].ID < result[j].ID
+		return less != backward
+	})
+
+	return result[:min(len(result), params.FetchLimit())]
+}
+
+func afterCursor(row pageRow, cursor *Cursor) bool {
+	score, id := int(cursor.Values[0].(int64)), int(cursor.Values[1].(int64))
+	after := row.Score < score || row.Score == score && row.ID > id
+	before := row.Score > score || row.Score == score && row.ID < id
+	if cursor.Backward {
+		return before
+	}
+	return after
+}
+
+func TestCursorPagesWalkForwardAndBackward(t *testing.T) {
+	paginator := New(Options{Secret: []byte("secret")})
+	keyset := Keyset{Columns: []KeysetColumn{{Name: "score", Desc: true}, {Name: "id"}}}
+	rows := []pageRow{{9, 1}, {7, 2}, {7, 3}, {5, 4}, {3, 5}}
+	key := func(row pageRow) []any { return []any{row.Score, row.ID} }
+
+	visit := func(link string) Page[pageRow] {
+		t.Helper()
+		request := httptest.NewRequest(http.MethodGet, link, nil)
+		params, err := paginator.Parse(request)
+		if err != nil {
+			t.Fatalf("Parse(%s) error = %v", link, err)
+		}
+		page, err := CursorPage(paginator, request, params, queryRows(t, keyset, params, rows), key)
+		if err != nil {
+			t.Fatalf("CursorPage() error = %v", err)
+		}
+		return page
+	}
+
+	first := visit("/scores?limit=2")
+	second := visit(first.Links.Next)
+	third := visit(second.Links.Next)
+	back := visit(third.Links.Prev)
+
+	if !reflect.DeepEqual(first.Items, rows[:2]) || first.Links.Prev != "" {
+		t.Fatalf("first page = %+v", first)
+	}
+	if !reflect.DeepEqual(second.Items, rows[2:4]) || second.Links.Prev == "" {
+		t.Fatalf("second page = %+v", second)
+	}
+	if !reflect.DeepEqual(third.Items, rows[4:]) || third.Links.Next != "" {
+		t.Fatalf("third page = %+v", third)
+	}
+	if !reflect.DeepEqual(back.Items, second.Items) || back.Links.Next == "" {
+		t.Fatalf("page before the third = %+v, want the second page", back)
+	}
+
+	firstAgain := visit(visit(back.Links.Prev).Links.Self)
+	if !reflect.DeepEqual(firstAgain.Items, first.Items) {
+		t.Fatalf("page before the second = %+v, want the first page", firstAgain)
+	}
+	if _, err := url.Parse(first.Links.Next); err != nil {
+		t.Fatalf("next link %q is not a URL: %v", first.Links.Next, err)
+	}
+}
+
+func TestKeysetSQL(t *testing.T) {
+	keyset := Keyset{
+		Columns:     []KeysetColumn{{Name: "created_at", Desc: true}, {Name: "id", Desc: true}},
+		Placeholder: PlaceholderFor("postgres"),
+	}
+	cursor := &Cursor{Values: []any{"2026-05-01", int64(7)}}
+
+	where, args, err := keyset.Where(cursor, 2)
+	if err != nil {
+		t.Fatalf("Where() error = %v", err)
+	}
+	if where != "((created_at < $2) OR (created_at = $3 AND id < $4))" {
+		t.Fatalf("where = %q", where)
+	}
+	if !reflect.DeepEqual(args, []any{"2026-05-01", "2026-05-01", int64(7)}) {
+		t.Fatalf("args = %v", args)
+	}
+	if orderBy := keyset.OrderBy(cursor); orderBy != "created_at DESC, id DESC" {
+		t.Fatalf("order by = %q", orderBy)
+	}
+
+	keyset.Placeholder = PlaceholderFor("sqlite")
+	cursor.Backward = true
+	if where, _, _ = keyset.Where(cursor, 1); where != "((created_at > ?) OR (created_at = ? AND id > ?))" {
+		t.Fatalf("backward where = %q", where)
+	}
+	if orderBy := keyset.OrderBy(cursor); orderBy != "created_at ASC, id ASC" {
+		t.Fatalf("backward order by = %q", orderBy)
+	}
+
+	if _, _, err := keyset.Where(&Cursor{Values: []any{1}}, 1); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("Where() error = %v, want ErrInvalidCursor for a cursor of another keyset", err)
+	}
+
+	// A nullable sort key must be sorted by COALESCE, a NULL matches no comparison
+	if _, _, err := keyset.Where(&Cursor{Values: []any{nil, 7}}, 1); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("Where() error = %v, want ErrInvalidCursor for a nil value", err)
+	}
+	paginator := New(Options{Secret: []byte("secret")})
+	if _, err := paginator.EncodeCursor("orders", Cursor{Values: []any{nil, 7}}); err == nil {
+		t.Fatal("EncodeCursor() error = nil, want nil values rejected")
+	}
+}
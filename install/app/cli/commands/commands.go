@@ -4,6 +4,7 @@ package commands
 
 import (
 	"github.com/golibry/go-cli-command/cli"
+	frameworkapp "github.com/golibry/go-web-skeleton/framework/app"
 	frameworkconfig "github.com/golibry/go-web-skeleton/framework/config"
 	frameworkhttp "github.com/golibry/go-web-skeleton/framework/http"
 	appregistry "{{MODULE_PATH}}/infrastructure/registry"
@@ -11,13 +12,33 @@ import (
 )
 
 func Registered(container *appregistry.Container) []cli.Command {
+	httpOptions := frameworkhttp.Options{
+		ServerConfig:   container.Config().HttpServer,
+		Logger:         container.Logger(),
+		RegisterRoutes: approutes.RegisterRoutes(container),
+		Lifecycle:      container.Lifecycle,
+		Middleware: frameworkhttp.MiddlewareOptions{
+			RequestScope: container,
+		},
+	}
+	dbStatsCommand := frameworkapp.NewDBStatsCommand(container)
+	if token := container.Config().Log.LogAdminToken; token != "" {
+		httpOptions.LogLevelAdmin = &frameworkhttp.LogLevelAdminOptions{
+			Token:         token,
+			LoggerService: container.LoggerService(),
+		}
+		httpOptions.DBStatsAdmin = &frameworkhttp.DBStatsAdminOptions{
+			Token:     token,
+			Databases: container.DBServices(),
+		}
+		dbStatsCommand.Token = token
+	}
+
 	commands := []cli.Command{
-		frameworkhttp.NewCommand(frameworkhttp.Options{
-			ServerConfig:   container.Config().HttpServer,
-			Logger:         container.Logger(),
-			RegisterRoutes: approutes.RegisterRoutes(container),
-		}),
+		frameworkhttp.NewCommand(httpOptions),
 		&frameworkconfig.DebugCommand{Cfg: container.Config()},
+		frameworkapp.NewDebugCommand(container),
+		dbStatsCommand,
 	}
 
 	return append(commands, migrationCommands(container)...)
@@ -9,6 +9,9 @@ type Config struct {
 	Log        basecfg.Log        `validate:"required"`
 	Database   basecfg.Database   `validate:"required"`
 	HttpServer basecfg.HttpServer `validate:"required"`
+
+	// Databases are the named databases configured by DB_<NAME>_DSN variables.
+	Databases basecfg.Databases `validate:"dive"`
 }
 
 func (c *Config) AppRef() *basecfg.App {
@@ -22,3 +25,7 @@ func (c *Config) LogConfig() basecfg.Log {
 func (c *Config) DatabaseConfig() *basecfg.Database {
 	return &c.Database
 }
+
+func (c *Config) DatabasesConfig() map[string]basecfg.Database {
+	return c.Databases
+}
@@ -3,13 +3,29 @@
 package commands
 
 import (
+	"sort"
+
 	"github.com/golibry/go-cli-command/cli"
 	frameworkmigrations "github.com/golibry/go-web-skeleton/framework/migrations"
 	appregistry "{{MODULE_PATH}}/infrastructure/registry"
 )
 
 func migrationCommands(container *appregistry.Container) []cli.Command {
-	return []cli.Command{
+	commands := []cli.Command{
 		frameworkmigrations.NewCommand(container.Config().Database),
 	}
+
+	names := make([]string, 0, len(container.Config().Databases))
+	for name := range container.Config().Databases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		commands = append(
+			commands,
+			frameworkmigrations.NewNamedCommand(name, container.Config().Databases[name]),
+		)
+	}
+
+	return commands
 }